@@ -5,17 +5,25 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/ccthomas/gridiron/internal/audit"
 	"github.com/ccthomas/gridiron/internal/system"
 	"github.com/ccthomas/gridiron/internal/team"
 	"github.com/ccthomas/gridiron/internal/tenant"
 	"github.com/ccthomas/gridiron/internal/useracc"
+	"github.com/ccthomas/gridiron/pkg/auth"
 	"github.com/ccthomas/gridiron/pkg/logger"
 	"github.com/ccthomas/gridiron/pkg/myhttp"
+	"github.com/ccthomas/gridiron/pkg/notify"
+	"github.com/ccthomas/gridiron/pkg/oidc"
 	"github.com/ccthomas/gridiron/pkg/rabbitmq"
+	"github.com/ccthomas/gridiron/pkg/signing"
+	"github.com/ccthomas/gridiron/pkg/throttle"
 	"github.com/gorilla/mux"
+	"go.uber.org/zap"
 )
 
 type Handlers struct {
+	AuditHandlers       *audit.AuditHandlers
 	SystemHandlers      *system.SystemHandlers
 	TeamHandlers        *team.TeamHandlers
 	TenantHandlers      *tenant.TenantHandlers
@@ -28,15 +36,26 @@ func NewHandlers(
 	teamRepo team.TeamRepository,
 	tenantRepo tenant.TenantRepository,
 	userRepo useracc.UserAccountRepository,
+	notifier notify.Notifier,
+	userLoginTracker *throttle.Tracker,
+	ipLoginTracker *throttle.Tracker,
+	keySet *signing.KeySet,
+	oidcProvider *oidc.Provider,
+	auditRepo audit.AuditRepository,
+	passwordHashPolicy useracc.PasswordHashPolicy,
+	quotaPolicy tenant.QuotaPolicy,
+	retentionPolicy tenant.RetentionPolicy,
 ) *Handlers {
 	logger.Get().Debug("Constructing new handlers")
 
+	auditHandlers := audit.NewHandlers(auditRepo)
 	systemHandlers := system.NewHandlers(db)
-	teamHandlers := team.NewHandlers(rmq, teamRepo)
-	tenantHandlers := tenant.NewHandlers(rmq, tenantRepo)
-	userAccHandlers := useracc.NewHandlers(tenantRepo, userRepo)
+	teamHandlers := team.NewHandlers(rmq, teamRepo, tenantRepo, quotaPolicy)
+	tenantHandlers := tenant.NewHandlers(rmq, tenantRepo, auditRepo, quotaPolicy, retentionPolicy)
+	userAccHandlers := useracc.NewHandlers(tenantRepo, userRepo, notifier, userLoginTracker, ipLoginTracker, keySet, oidcProvider, auditRepo, passwordHashPolicy)
 
 	return &Handlers{
+		AuditHandlers:       auditHandlers,
 		SystemHandlers:      systemHandlers,
 		TeamHandlers:        teamHandlers,
 		TenantHandlers:      tenantHandlers,
@@ -48,8 +67,9 @@ func (h *Handlers) RouteApis(r *mux.Router, rmq *rabbitmq.RabbitMqRouter) {
 	logger.Get().Debug("Route apis")
 	h.routeSystemApis(r)
 	h.routeTeamApis(r, rmq)
-	h.routeTenantApis(r)
+	h.routeTenantApis(r, rmq)
 	h.routeUserAccountApis(r)
+	h.routeWellKnownApis(r)
 }
 
 func (h *Handlers) routeSystemApis(r *mux.Router) {
@@ -64,18 +84,51 @@ func (h *Handlers) routeTeamApis(r *mux.Router, rmq *rabbitmq.RabbitMqRouter) {
 	logger.Get().Debug("Configuring team handler routes")
 	tenantRoutes := r.PathPrefix("/team").Subrouter()
 
-	tenantRoutes.HandleFunc("", h.tokenAuthorizer(h.TeamHandlers.CreateNewTeamHandler)).Methods("POST")
-	tenantRoutes.HandleFunc("", h.tokenAuthorizer(h.TeamHandlers.GetAllTeamsHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("", h.tenantRoute(auth.TeamWrite, h.TeamHandlers.CreateNewTeamHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("", h.tenantRoute(auth.TeamRead, h.TeamHandlers.GetAllTeamsHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/{teamId}", h.tenantRoute(auth.TeamRead, h.TeamHandlers.GetTeamHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/{teamId}", h.tenantRoute(auth.TeamWrite, h.TeamHandlers.UpdateTeamHandler)).Methods("PATCH")
+	tenantRoutes.HandleFunc("/{teamId}", h.tenantRoute(auth.TeamWrite, h.TeamHandlers.DeleteTeamHandler)).Methods("DELETE")
 
-	rmq.HandleFunc(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), "New Tenant", h.TeamHandlers.ProcessNewTenantMessageHandler)
+	r.HandleFunc("/league-templates", h.tokenAuthorizer(h.TeamHandlers.GetLeagueTemplatesHandler)).Methods("GET")
+
+	rmq.HandleFunc(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), tenant.NewTenantMessageKey, h.TeamHandlers.ProcessNewTenantMessageHandler)
 }
 
-func (h *Handlers) routeTenantApis(r *mux.Router) {
+func (h *Handlers) routeTenantApis(r *mux.Router, rmq *rabbitmq.RabbitMqRouter) {
 	logger.Get().Debug("Configuring tenant handler routes")
 	tenantRoutes := r.PathPrefix("/tenant").Subrouter()
 
 	tenantRoutes.HandleFunc("", h.tokenAuthorizer(h.TenantHandlers.GetAllTenantsHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/invitations", h.tokenAuthorizer(h.TenantHandlers.GetMyInvitationsHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/invitations/{invitationId}/accept", h.tokenAuthorizer(h.TenantHandlers.AcceptInvitationHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("/invitations/{invitationId}/decline", h.tokenAuthorizer(h.TenantHandlers.DeclineInvitationHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("/ownership-transfers", h.tokenAuthorizer(h.TenantHandlers.GetMyOwnershipTransfersHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/ownership-transfers/{transferId}/accept", h.tokenAuthorizer(h.TenantHandlers.AcceptOwnershipTransferHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("/ownership-transfers/{transferId}/decline", h.tokenAuthorizer(h.TenantHandlers.DeclineOwnershipTransferHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("/clones/{cloneId}", h.tokenAuthorizer(h.TenantHandlers.GetCloneHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/import", h.tokenAuthorizer(h.TenantHandlers.ImportTenantHandler)).Methods("POST")
 	tenantRoutes.HandleFunc("/{name}", h.tokenAuthorizer(h.TenantHandlers.NewTenantHandler)).Methods("POST")
+
+	tenantRoutes.HandleFunc("/{tenantId}", h.tenantRoute(auth.TenantRead, h.TenantHandlers.GetTenantHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/{tenantId}", h.tenantRoute(auth.TenantManage, h.TenantHandlers.UpdateTenantHandler)).Methods("PATCH")
+	tenantRoutes.HandleFunc("/{tenantId}", h.tenantRoute(auth.TenantDelete, h.TenantHandlers.DeleteTenantHandler)).Methods("DELETE")
+	tenantRoutes.HandleFunc("/{tenantId}/restore", h.tokenAuthorizer(h.TenantHandlers.RestoreTenantHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("/{tenantId}/clone", h.tenantRoute(auth.TenantManage, h.TenantHandlers.NewCloneHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("/{tenantId}/export", h.tenantRoute(auth.TenantManage, h.TenantHandlers.ExportTenantHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/{tenantId}/transfer-ownership", h.tenantRoute(auth.TenantTransfer, h.TenantHandlers.NewOwnershipTransferHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("/{tenantId}/invitations", h.tenantRoute(auth.TenantManage, h.TenantHandlers.NewInvitationHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("/{tenantId}/members", h.tenantRoute(auth.TenantRead, h.TenantHandlers.GetMembersHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/{tenantId}/members/{userId}", h.tenantRoute(auth.TenantManage, h.TenantHandlers.UpdateMemberHandler)).Methods("PATCH")
+	tenantRoutes.HandleFunc("/{tenantId}/members/{userId}", h.tenantRoute(auth.TenantManage, h.TenantHandlers.RemoveMemberHandler)).Methods("DELETE")
+	tenantRoutes.HandleFunc("/{tenantId}/api-keys", h.tenantRoute(auth.TenantManage, h.TenantHandlers.NewApiKeyHandler)).Methods("POST")
+	tenantRoutes.HandleFunc("/{tenantId}/api-keys", h.tenantRoute(auth.TenantManage, h.TenantHandlers.GetAllApiKeysHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/{tenantId}/api-keys/{apiKeyId}", h.tenantRoute(auth.TenantManage, h.TenantHandlers.RevokeApiKeyHandler)).Methods("DELETE")
+	tenantRoutes.HandleFunc("/{tenantId}/audit-log", h.tenantRoute(auth.AuditRead, h.AuditHandlers.GetAuditLogHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/{tenantId}/usage", h.tenantRoute(auth.TenantRead, h.TenantHandlers.GetUsageHandler)).Methods("GET")
+	tenantRoutes.HandleFunc("/{tenantId}/quota", h.systemAdminAuthorizer(h.TenantHandlers.UpdateQuotaHandler)).Methods("PUT")
+
+	rmq.HandleFunc(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), tenant.TenantCloneRequestedMessageKey, h.TenantHandlers.ProcessCloneRequestedMessageHandler)
 }
 
 func (h *Handlers) routeUserAccountApis(r *mux.Router) {
@@ -83,10 +136,34 @@ func (h *Handlers) routeUserAccountApis(r *mux.Router) {
 	systemRoutes := r.PathPrefix("/user").Subrouter()
 
 	systemRoutes.HandleFunc("", h.UserAccountHandlers.CreateNewUserHandler).Methods("POST")
+	systemRoutes.HandleFunc("/me", h.tokenAuthorizer(h.UserAccountHandlers.GetProfileHandler)).Methods("GET")
+	systemRoutes.HandleFunc("/me", h.tokenAuthorizer(h.UserAccountHandlers.UpdateProfileHandler)).Methods("PATCH")
+	systemRoutes.HandleFunc("/me", h.tokenAuthorizer(h.UserAccountHandlers.DeleteAccountHandler)).Methods("DELETE")
 	systemRoutes.HandleFunc("/login", h.UserAccountHandlers.LoginHandler).Methods("POST")
+	systemRoutes.HandleFunc("/login/mfa", h.UserAccountHandlers.MfaLoginHandler).Methods("POST")
+	systemRoutes.HandleFunc("/mfa/totp", h.tokenAuthorizer(h.UserAccountHandlers.EnrollTotpHandler)).Methods("POST")
+	systemRoutes.HandleFunc("/mfa/totp/verify", h.tokenAuthorizer(h.UserAccountHandlers.VerifyTotpHandler)).Methods("POST")
+	systemRoutes.HandleFunc("/mfa/totp", h.tokenAuthorizer(h.UserAccountHandlers.DisableTotpHandler)).Methods("DELETE")
+	systemRoutes.HandleFunc("/oidc/login", h.UserAccountHandlers.OidcLoginHandler).Methods("GET")
+	systemRoutes.HandleFunc("/oidc/link", h.tokenAuthorizer(h.UserAccountHandlers.OidcLinkHandler)).Methods("POST")
+	systemRoutes.HandleFunc("/oidc/callback", h.UserAccountHandlers.OidcCallbackHandler).Methods("GET")
+	systemRoutes.HandleFunc("/logout", h.tokenAuthorizer(h.UserAccountHandlers.LogoutHandler)).Methods("POST")
+	systemRoutes.HandleFunc("/token/refresh", h.UserAccountHandlers.RefreshTokenHandler).Methods("POST")
+	systemRoutes.HandleFunc("/password", h.tokenAuthorizer(h.UserAccountHandlers.ChangePasswordHandler)).Methods("PUT")
+	systemRoutes.HandleFunc("/password/reset", h.UserAccountHandlers.PasswordResetRequestHandler).Methods("POST")
+	systemRoutes.HandleFunc("/password/reset/confirm", h.UserAccountHandlers.PasswordResetConfirmHandler).Methods("POST")
+	systemRoutes.HandleFunc("/admin/unlock", h.systemAdminAuthorizer(h.UserAccountHandlers.UnlockAccountHandler)).Methods("POST")
+	systemRoutes.HandleFunc("/admin/lock-events", h.systemAdminAuthorizer(h.UserAccountHandlers.GetLockEventsHandler)).Methods("GET")
 	systemRoutes.HandleFunc("/authorizer-context", h.tokenAuthorizer(h.UserAccountHandlers.GetAuthorizerContextHandler)).Methods("GET")
 }
 
+func (h *Handlers) routeWellKnownApis(r *mux.Router) {
+	logger.Get().Debug("Configuring well known routes")
+	wellKnownRoutes := r.PathPrefix("/.well-known").Subrouter()
+
+	wellKnownRoutes.HandleFunc("/jwks.json", h.UserAccountHandlers.JwksHandler).Methods("GET")
+}
+
 func (h *Handlers) tokenAuthorizer(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger.Get().Debug("Token Authorizer")
@@ -101,7 +178,7 @@ func (h *Handlers) tokenAuthorizer(next http.HandlerFunc) http.HandlerFunc {
 		}
 
 		logger.Get().Debug("Authorize request.")
-		err := h.UserAccountHandlers.TokenAuthorizerHandler(w, r)
+		r, err := h.UserAccountHandlers.TokenAuthorizerHandler(w, r)
 		if err != nil {
 			logger.Get().Warn("Is not authorized.")
 			return
@@ -112,3 +189,165 @@ func (h *Handlers) tokenAuthorizer(next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(w, r)
 	}
 }
+
+// systemAdminAuthorizer authorizes the token and requires the caller to be a system administrator.
+func (h *Handlers) systemAdminAuthorizer(next http.HandlerFunc) http.HandlerFunc {
+	return h.tokenAuthorizer(func(w http.ResponseWriter, r *http.Request) {
+		logger.Get().Debug("System Admin Authorizer")
+
+		ctx, ok := auth.FromContext(r.Context())
+		if !ok {
+			logger.Get().Debug("Failed to get authorizer context from request")
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+
+		if !auth.IsSystemAdmin(ctx.UserId) {
+			logger.Get().Warn("User is not a system administrator.", zap.String("UserId", ctx.UserId))
+			myhttp.WriteError(w, http.StatusForbidden, "User is not a system administrator.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tenantRoute composes the middleware shared by every tenant scoped route:
+// the token is authorized, the request is scoped to a tenant, the caller must
+// hold the permission within that tenant, and only then is the call counted
+// against the tenant's daily api call quota.
+func (h *Handlers) tenantRoute(permission auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return h.credentialAuthorizer(h.tenantScope(h.requirePermission(permission, h.apiCallQuota(next))))
+}
+
+// credentialAuthorizer accepts either a tenant api key in the X-API-Key header
+// or a bearer token. Only tenant scoped routes accept api keys.
+func (h *Handlers) credentialAuthorizer(next http.HandlerFunc) http.HandlerFunc {
+	authorizeToken := h.tokenAuthorizer(next)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") == "" {
+			authorizeToken(w, r)
+			return
+		}
+
+		logger.Get().Debug("Authorize api key.")
+		r, err := h.UserAccountHandlers.ApiKeyAuthorizerHandler(w, r)
+		if err != nil {
+			logger.Get().Warn("Is not authorized.")
+			return
+		}
+
+		logger.Get().Debug("Is Authorized!")
+		next.ServeHTTP(w, r)
+	}
+}
+
+// tenantScope resolves the tenant from the {tenantId} path parameter, falling back
+// to the x-tenant-id header, verifies the caller is a member of it and injects it
+// into the request context.
+func (h *Handlers) tenantScope(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Get().Debug("Tenant Scope")
+
+		ctx, ok := auth.FromContext(r.Context())
+		if !ok {
+			logger.Get().Debug("Failed to get authorizer context from request")
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+
+		tenantId := mux.Vars(r)["tenantId"]
+		if tenantId == "" {
+			tenantId = r.Header.Get("x-tenant-id")
+		}
+
+		if tenantId == "" {
+			logger.Get().Debug("Tenant id header not provided.")
+			myhttp.WriteError(w, http.StatusBadRequest, "x-tenant-id header is missing.")
+			return
+		}
+
+		if _, ok := ctx.TenantAccess[tenantId]; !ok {
+			logger.Get().Warn("User does not have access to tenant.", zap.String("TenantId", tenantId))
+			audit.Record(h.AuditHandlers.AuditRepository, r, audit.AuditEvent{
+				TenantId: &tenantId,
+				Action:   audit.TenantAccess,
+				Outcome:  audit.Denied,
+				Target:   audit.String(r.URL.Path),
+			})
+			myhttp.WriteError(w, http.StatusForbidden, "User is unauthorized to access tenant.")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithTenantId(r.Context(), tenantId)))
+	}
+}
+
+// requirePermission rejects the request unless the caller holds the permission
+// within the tenant the request was scoped to. It must be wrapped by tenantScope.
+func (h *Handlers) requirePermission(permission auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Get().Debug("Require Permission", zap.String("Permission", string(permission)))
+
+		ctx, ok := auth.FromContext(r.Context())
+		if !ok {
+			logger.Get().Debug("Failed to get authorizer context from request")
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+
+		tenantId, ok := auth.TenantIdFromContext(r.Context())
+		if !ok {
+			logger.Get().Error("Request was not scoped to a tenant.")
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+
+		if !ctx.HasPermission(tenantId, permission) {
+			logger.Get().Debug("User does not have permission.")
+			audit.Record(h.AuditHandlers.AuditRepository, r, audit.AuditEvent{
+				TenantId: &tenantId,
+				Action:   audit.TenantPermission,
+				Outcome:  audit.Denied,
+				Target:   audit.String(r.URL.Path),
+				Detail:   audit.String(string(permission)),
+			})
+			myhttp.WriteError(w, http.StatusForbidden, "User does not have permission to perform this action.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// apiCallQuota counts the call against the daily api call quota of the tenant the
+// request was scoped to and rejects it once the quota is used up. Calls that cannot
+// be counted are rejected. It must be wrapped by tenantScope.
+func (h *Handlers) apiCallQuota(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Get().Debug("Api Call Quota")
+
+		tenantId, ok := auth.TenantIdFromContext(r.Context())
+		if !ok {
+			logger.Get().Error("Request was not scoped to a tenant.")
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+
+		allowed, err := h.TenantHandlers.ApiCallAllowed(tenantId)
+		if err != nil {
+			logger.Get().Error("Failed to count api call.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+
+		if !allowed {
+			logger.Get().Warn("Tenant reached daily api call quota.", zap.String("TenantId", tenantId))
+			myhttp.WriteError(w, http.StatusTooManyRequests, "Daily api call quota exceeded.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_account.refresh_token;
+DROP TABLE IF EXISTS user_account.user_session;
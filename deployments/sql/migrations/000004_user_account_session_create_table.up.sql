@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS user_account.user_session (
+    id VARCHAR(255) PRIMARY KEY,
+    user_account_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    FOREIGN KEY (user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_account.refresh_token (
+    token_hash VARCHAR(255) PRIMARY KEY,
+    session_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    FOREIGN KEY (session_id) REFERENCES user_account.user_session(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE tenant.tenant_user_access DROP CONSTRAINT IF EXISTS tenant_user_access_access_level_check;
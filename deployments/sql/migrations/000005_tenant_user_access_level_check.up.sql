@@ -0,0 +1,3 @@
+ALTER TABLE tenant.tenant_user_access
+    ADD CONSTRAINT tenant_user_access_access_level_check
+    CHECK (access_level IN ('OWNER', 'ADMIN', 'EDITOR', 'SCORER', 'VIEWER'));
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS tenant.tenant_invitation (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    user_account_id VARCHAR(255) NOT NULL,
+    access_level VARCHAR(255) NOT NULL,
+    invited_by VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL DEFAULT 'PENDING',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (tenant_id) REFERENCES tenant.tenant(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS tenant_invitation_user_account_id_idx ON tenant.tenant_invitation (user_account_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_account.password_reset_token;
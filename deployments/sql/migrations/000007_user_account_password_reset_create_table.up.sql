@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user_account.password_reset_token (
+    token_hash VARCHAR(255) PRIMARY KEY,
+    user_account_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    FOREIGN KEY (user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
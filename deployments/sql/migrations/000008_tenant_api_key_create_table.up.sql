@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS tenant.api_key (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    "name" VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(255) UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    FOREIGN KEY (tenant_id) REFERENCES tenant.tenant(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_account.account_lock_event;
+DROP TABLE IF EXISTS user_account.login_attempt;
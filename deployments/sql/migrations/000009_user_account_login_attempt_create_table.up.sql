@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS user_account.login_attempt (
+    attempt_key VARCHAR(255) PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS user_account.account_lock_event (
+    id VARCHAR(255) PRIMARY KEY,
+    username VARCHAR(255) NOT NULL,
+    "event" VARCHAR(255) NOT NULL,
+    actor_user_account_id VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS account_lock_event_username_idx ON user_account.account_lock_event (username);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS user_account.user_account_email_key;
+
+ALTER TABLE user_account.user_account
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS favorite_team,
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS email,
+    DROP COLUMN IF EXISTS display_name;
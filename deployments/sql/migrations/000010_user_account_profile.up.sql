@@ -0,0 +1,9 @@
+ALTER TABLE user_account.user_account
+    ADD COLUMN IF NOT EXISTS display_name VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS email VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS favorite_team VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_account_email_key ON user_account.user_account (LOWER(email));
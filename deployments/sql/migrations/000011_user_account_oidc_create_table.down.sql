@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_account.external_identity;
+DROP TABLE IF EXISTS user_account.oidc_login_state;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS user_account.oidc_login_state (
+    state_hash VARCHAR(255) PRIMARY KEY,
+    nonce VARCHAR(255) NOT NULL,
+    code_verifier VARCHAR(255) NOT NULL,
+    user_account_id VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_account.external_identity (
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_account_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS external_identity_user_account_id_idx ON user_account.external_identity (user_account_id);
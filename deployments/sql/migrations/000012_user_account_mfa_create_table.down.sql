@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_account.mfa_challenge;
+DROP TABLE IF EXISTS user_account.mfa_recovery_code;
+DROP TABLE IF EXISTS user_account.user_mfa;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS user_account.user_mfa (
+    user_account_id VARCHAR(255) PRIMARY KEY,
+    totp_secret VARCHAR(255) NOT NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    enabled_at TIMESTAMPTZ,
+    FOREIGN KEY (user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_account.mfa_recovery_code (
+    code_hash VARCHAR(255) PRIMARY KEY,
+    user_account_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    used_at TIMESTAMPTZ,
+    FOREIGN KEY (user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_account.mfa_challenge (
+    token_hash VARCHAR(255) PRIMARY KEY,
+    user_account_id VARCHAR(255) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
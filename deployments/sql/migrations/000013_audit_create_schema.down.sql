@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS audit.audit_event;
+DROP SCHEMA IF EXISTS audit;
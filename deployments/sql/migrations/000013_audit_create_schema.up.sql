@@ -0,0 +1,18 @@
+CREATE SCHEMA IF NOT EXISTS audit;
+
+CREATE TABLE IF NOT EXISTS audit.audit_event (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255),
+    actor_user_account_id VARCHAR(255),
+    actor_api_key_id VARCHAR(255),
+    "action" VARCHAR(255) NOT NULL,
+    outcome VARCHAR(255) NOT NULL,
+    target VARCHAR(255),
+    detail TEXT,
+    ip VARCHAR(255),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_event_tenant_id_created_at_idx ON audit.audit_event (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_event_actor_user_account_id_idx ON audit.audit_event (actor_user_account_id);
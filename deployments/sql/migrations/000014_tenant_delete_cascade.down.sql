@@ -0,0 +1,7 @@
+ALTER TABLE team.team
+    DROP CONSTRAINT IF EXISTS team_tenant_id_fkey,
+    ADD CONSTRAINT team_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenant.tenant(id);
+
+ALTER TABLE tenant.tenant_user_access
+    DROP CONSTRAINT IF EXISTS tenant_user_access_tenant_id_fkey,
+    ADD CONSTRAINT tenant_user_access_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenant.tenant(id);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS tenant.ownership_transfer (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    from_user_account_id VARCHAR(255) NOT NULL,
+    to_user_account_id VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL DEFAULT 'PENDING',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (tenant_id) REFERENCES tenant.tenant(id) ON DELETE CASCADE,
+    FOREIGN KEY (from_user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_user_account_id) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ownership_transfer_to_user_account_id_idx ON tenant.ownership_transfer (to_user_account_id);
+
+-- Only one transfer per tenant may be pending at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS ownership_transfer_pending_tenant_id_key ON tenant.ownership_transfer (tenant_id) WHERE status = 'PENDING';
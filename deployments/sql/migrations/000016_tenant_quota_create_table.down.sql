@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS tenant.api_usage;
+DROP TABLE IF EXISTS tenant.tenant_quota;
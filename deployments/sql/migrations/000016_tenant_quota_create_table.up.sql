@@ -0,0 +1,19 @@
+-- Per tenant quota overrides. Tenants without a row use the configured defaults.
+CREATE TABLE IF NOT EXISTS tenant.tenant_quota (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    max_teams BIGINT NOT NULL,
+    max_players BIGINT NOT NULL,
+    max_members BIGINT NOT NULL,
+    max_api_calls_per_day BIGINT NOT NULL,
+    max_storage_bytes BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (tenant_id) REFERENCES tenant.tenant(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS tenant.api_usage (
+    tenant_id VARCHAR(255) NOT NULL,
+    day DATE NOT NULL,
+    calls BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, day),
+    FOREIGN KEY (tenant_id) REFERENCES tenant.tenant(id) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+-- The target tenant is only created once the clone completes, so it has no foreign key.
+CREATE TABLE IF NOT EXISTS tenant.tenant_clone (
+    id VARCHAR(255) PRIMARY KEY,
+    source_tenant_id VARCHAR(255) NOT NULL,
+    target_tenant_id VARCHAR(255) NOT NULL,
+    target_name VARCHAR(255) NOT NULL,
+    requested_by VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL DEFAULT 'PENDING',
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    FOREIGN KEY (source_tenant_id) REFERENCES tenant.tenant(id) ON DELETE CASCADE,
+    FOREIGN KEY (requested_by) REFERENCES user_account.user_account(id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+DROP POLICY IF EXISTS audit_event_tenant_isolation ON audit.audit_event;
+ALTER TABLE audit.audit_event DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS team_tenant_isolation ON team.team;
+ALTER TABLE team.team DISABLE ROW LEVEL SECURITY;
+
+REVOKE ALL ON audit.audit_event FROM gridiron_tenant;
+REVOKE ALL ON team.team FROM gridiron_tenant;
+REVOKE USAGE ON SCHEMA team, audit FROM gridiron_tenant;
+
+DROP ROLE IF EXISTS gridiron_tenant;
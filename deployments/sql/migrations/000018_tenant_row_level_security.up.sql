@@ -0,0 +1,25 @@
+-- Repositories switch to this role for the length of a tenant transaction, so the policies
+-- below apply even when the application connects as the table owner or a superuser.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'gridiron_tenant') THEN
+        CREATE ROLE gridiron_tenant NOLOGIN;
+    END IF;
+END
+$$;
+
+GRANT gridiron_tenant TO CURRENT_USER;
+
+GRANT USAGE ON SCHEMA team, audit TO gridiron_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON team.team TO gridiron_tenant;
+GRANT SELECT ON audit.audit_event TO gridiron_tenant;
+
+-- Policies only apply to gridiron_tenant. A transaction without app.current_tenant set sees no rows.
+ALTER TABLE team.team ENABLE ROW LEVEL SECURITY;
+CREATE POLICY team_tenant_isolation ON team.team TO gridiron_tenant
+    USING (tenant_id = current_setting('app.current_tenant', true))
+    WITH CHECK (tenant_id = current_setting('app.current_tenant', true));
+
+ALTER TABLE audit.audit_event ENABLE ROW LEVEL SECURITY;
+CREATE POLICY audit_event_tenant_isolation ON audit.audit_event TO gridiron_tenant
+    USING (tenant_id = current_setting('app.current_tenant', true));
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tenant.tenant_deleted_at_idx;
+
+ALTER TABLE tenant.tenant DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,4 @@
+-- Deleted tenants are kept until the purge job removes them after the retention window.
+ALTER TABLE tenant.tenant ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS tenant_deleted_at_idx ON tenant.tenant (deleted_at) WHERE deleted_at IS NOT NULL;
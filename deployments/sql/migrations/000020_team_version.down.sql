@@ -0,0 +1 @@
+ALTER TABLE team.team DROP COLUMN IF EXISTS version;
@@ -0,0 +1,2 @@
+-- Incremented on every update so concurrent edits of a team can be detected.
+ALTER TABLE team.team ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,11 @@
+ALTER TABLE team.team DROP CONSTRAINT IF EXISTS team_tenant_abbreviation_key;
+
+ALTER TABLE team.team
+    DROP COLUMN IF EXISTS abbreviation,
+    DROP COLUMN IF EXISTS location,
+    DROP COLUMN IF EXISTS nickname,
+    DROP COLUMN IF EXISTS primary_color,
+    DROP COLUMN IF EXISTS secondary_color,
+    DROP COLUMN IF EXISTS venue,
+    DROP COLUMN IF EXISTS founded_year,
+    DROP COLUMN IF EXISTS active;
@@ -0,0 +1,12 @@
+-- Abbreviations are stored upper case. NULL abbreviations do not conflict with each other.
+ALTER TABLE team.team
+    ADD COLUMN IF NOT EXISTS abbreviation VARCHAR(5),
+    ADD COLUMN IF NOT EXISTS location VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS nickname VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS primary_color VARCHAR(7) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS secondary_color VARCHAR(7) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS venue VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS founded_year INTEGER,
+    ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE team.team ADD CONSTRAINT team_tenant_abbreviation_key UNIQUE (tenant_id, abbreviation);
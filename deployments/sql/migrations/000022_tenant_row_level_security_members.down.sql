@@ -0,0 +1,17 @@
+DROP POLICY IF EXISTS api_usage_tenant_isolation ON tenant.api_usage;
+ALTER TABLE tenant.api_usage DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS api_key_tenant_isolation ON tenant.api_key;
+ALTER TABLE tenant.api_key DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_invitation_tenant_isolation ON tenant.tenant_invitation;
+ALTER TABLE tenant.tenant_invitation DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_user_access_tenant_isolation ON tenant.tenant_user_access;
+ALTER TABLE tenant.tenant_user_access DISABLE ROW LEVEL SECURITY;
+
+REVOKE ALL ON tenant.api_usage FROM gridiron_tenant;
+REVOKE ALL ON tenant.api_key FROM gridiron_tenant;
+REVOKE ALL ON tenant.tenant_invitation FROM gridiron_tenant;
+REVOKE ALL ON tenant.tenant_user_access FROM gridiron_tenant;
+REVOKE USAGE ON SCHEMA tenant FROM gridiron_tenant;
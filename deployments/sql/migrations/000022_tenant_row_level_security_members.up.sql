@@ -0,0 +1,25 @@
+-- Extends the policies of 000018 to the tenant's members, invitations, api keys and api usage.
+GRANT USAGE ON SCHEMA tenant TO gridiron_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON tenant.tenant_user_access TO gridiron_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON tenant.tenant_invitation TO gridiron_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON tenant.api_key TO gridiron_tenant;
+GRANT SELECT ON tenant.api_usage TO gridiron_tenant;
+
+ALTER TABLE tenant.tenant_user_access ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_user_access_tenant_isolation ON tenant.tenant_user_access TO gridiron_tenant
+    USING (tenant_id = current_setting('app.current_tenant', true))
+    WITH CHECK (tenant_id = current_setting('app.current_tenant', true));
+
+ALTER TABLE tenant.tenant_invitation ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_invitation_tenant_isolation ON tenant.tenant_invitation TO gridiron_tenant
+    USING (tenant_id = current_setting('app.current_tenant', true))
+    WITH CHECK (tenant_id = current_setting('app.current_tenant', true));
+
+ALTER TABLE tenant.api_key ENABLE ROW LEVEL SECURITY;
+CREATE POLICY api_key_tenant_isolation ON tenant.api_key TO gridiron_tenant
+    USING (tenant_id = current_setting('app.current_tenant', true))
+    WITH CHECK (tenant_id = current_setting('app.current_tenant', true));
+
+ALTER TABLE tenant.api_usage ENABLE ROW LEVEL SECURITY;
+CREATE POLICY api_usage_tenant_isolation ON tenant.api_usage TO gridiron_tenant
+    USING (tenant_id = current_setting('app.current_tenant', true));
@@ -0,0 +1,7 @@
+DROP POLICY IF EXISTS tenant_tenant_isolation ON tenant.tenant;
+ALTER TABLE tenant.tenant DISABLE ROW LEVEL SECURITY;
+
+REVOKE INSERT, UPDATE ON tenant.api_usage FROM gridiron_tenant;
+REVOKE ALL ON tenant.tenant FROM gridiron_tenant;
+REVOKE ALL ON user_account.user_account FROM gridiron_tenant;
+REVOKE USAGE ON SCHEMA user_account FROM gridiron_tenant;
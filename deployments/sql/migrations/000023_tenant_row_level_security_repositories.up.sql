@@ -0,0 +1,10 @@
+-- Lets tenant transactions read their own tenant, the usernames of members and count api calls,
+-- so every tenant scoped repository method can run as gridiron_tenant.
+GRANT USAGE ON SCHEMA user_account TO gridiron_tenant;
+GRANT SELECT (id, username) ON user_account.user_account TO gridiron_tenant;
+GRANT SELECT ON tenant.tenant TO gridiron_tenant;
+GRANT INSERT, UPDATE ON tenant.api_usage TO gridiron_tenant;
+
+ALTER TABLE tenant.tenant ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_tenant_isolation ON tenant.tenant FOR SELECT TO gridiron_tenant
+    USING (id = current_setting('app.current_tenant', true));
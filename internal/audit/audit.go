@@ -0,0 +1,93 @@
+package audit
+
+import "time"
+
+// Constants
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+type Action string
+
+const (
+	UserLogin          Action = "user.login"
+	UserLoginMfa       Action = "user.login.mfa"
+	UserLoginOidc      Action = "user.login.oidc"
+	UserToken          Action = "user.token"
+	UserApiKey         Action = "user.api_key"
+	UserPasswordChange Action = "user.password.change"
+
+	TenantCreate                   Action = "tenant.create"
+	TenantUpdate                   Action = "tenant.update"
+	TenantDelete                   Action = "tenant.delete"
+	TenantRestore                  Action = "tenant.restore"
+	TenantExport                   Action = "tenant.export"
+	TenantImport                   Action = "tenant.import"
+	TenantClone                    Action = "tenant.clone"
+	TenantAccess                   Action = "tenant.access"
+	TenantPermission               Action = "tenant.permission"
+	TenantInvitationCreate         Action = "tenant.invitation.create"
+	TenantInvitationAccept         Action = "tenant.invitation.accept"
+	TenantInvitationDecline        Action = "tenant.invitation.decline"
+	TenantMemberUpdate             Action = "tenant.member.update"
+	TenantMemberRemove             Action = "tenant.member.remove"
+	TenantOwnershipTransferCreate  Action = "tenant.ownership_transfer.create"
+	TenantOwnershipTransferAccept  Action = "tenant.ownership_transfer.accept"
+	TenantOwnershipTransferDecline Action = "tenant.ownership_transfer.decline"
+	TenantApiKeyCreate             Action = "tenant.api_key.create"
+	TenantApiKeyRevoke             Action = "tenant.api_key.revoke"
+)
+
+type Outcome string
+
+const (
+	Success Outcome = "SUCCESS"
+	Failure Outcome = "FAILURE"
+	Denied  Outcome = "DENIED"
+)
+
+// Data Transfer Objects
+
+type AuditEventGetAllDTO struct {
+	Count int          `json:"count"`
+	Data  []AuditEvent `json:"data"`
+}
+
+// Entities
+
+// AuditEvent records who did what, to what, and whether it was allowed. Events are
+// kept when the tenant or user they refer to is deleted, so ids are not foreign keys.
+type AuditEvent struct {
+	Id                 string    `json:"id"`
+	TenantId           *string   `json:"tenant_id"`
+	ActorUserAccountId *string   `json:"actor_user_account_id"`
+	ActorApiKeyId      *string   `json:"actor_api_key_id"`
+	Action             Action    `json:"action"`
+	Outcome            Outcome   `json:"outcome"`
+	Target             *string   `json:"target"`
+	Detail             *string   `json:"detail"`
+	Ip                 string    `json:"ip"`
+	UserAgent          string    `json:"user_agent"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+type AuditEventFilter struct {
+	TenantId           string
+	ActorUserAccountId string
+	From               *time.Time
+	To                 *time.Time
+	Limit              int
+}
+
+// Interfaces
+
+type AuditHandlers struct {
+	AuditRepository AuditRepository
+}
+
+type AuditRepository interface {
+	InsertEvent(event AuditEvent) error
+	SelectEvents(filter AuditEventFilter) ([]AuditEvent, error)
+}
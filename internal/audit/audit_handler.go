@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/logger"
+	"github.com/ccthomas/gridiron/pkg/myhttp"
+	"go.uber.org/zap"
+)
+
+func NewHandlers(auditRepository AuditRepository) *AuditHandlers {
+	logger.Get().Debug("Constructing audit handlers")
+	return &AuditHandlers{
+		AuditRepository: auditRepository,
+	}
+}
+
+// GetAuditLogHandler lists the audit events of the tenant the request is scoped to.
+// The from and to query parameters are RFC 3339 timestamps, actor is a user account id
+// and limit caps the number of events returned.
+func (h *AuditHandlers) GetAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get Audit Log Handler hit.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get tenant id from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Build filter from query params.")
+	query := r.URL.Query()
+	filter := AuditEventFilter{
+		TenantId:           tenantId,
+		ActorUserAccountId: query.Get("actor"),
+		Limit:              DefaultPageSize,
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			logger.Get().Debug("Failed to parse from query param.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusBadRequest, "from must be an RFC 3339 timestamp.")
+			return
+		}
+
+		filter.From = &t
+	}
+
+	if to := query.Get("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			logger.Get().Debug("Failed to parse to query param.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusBadRequest, "to must be an RFC 3339 timestamp.")
+			return
+		}
+
+		filter.To = &t
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxPageSize {
+			logger.Get().Debug("Invalid limit query param.", zap.String("Limit", limit))
+			myhttp.WriteError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(MaxPageSize)+".")
+			return
+		}
+
+		filter.Limit = n
+	}
+
+	events, err := h.AuditRepository.SelectEvents(filter)
+	if err != nil {
+		logger.Logger.Error("Failed to select audit events.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var jsonResponse []byte = []byte(`{"count":0,"data":[]}`)
+	if len(events) != 0 {
+		jsonResponse, err = json.Marshal(&AuditEventGetAllDTO{
+			Count: len(events),
+			Data:  events,
+		})
+		if err != nil {
+			logger.Logger.Error("Failed to marshal response.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
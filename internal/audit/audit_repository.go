@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ccthomas/gridiron/pkg/database"
+	"github.com/ccthomas/gridiron/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type AuditRepositoryImpl struct {
+	DB *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepositoryImpl {
+	logger.Get().Debug("Construct new audit repository.")
+	return &AuditRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (r *AuditRepositoryImpl) InsertEvent(event AuditEvent) error {
+	logger.Get().Debug("Insert audit event.", zap.String("Action", string(event.Action)))
+	_, err := r.DB.Exec(
+		"INSERT INTO audit.audit_event (id, tenant_id, actor_user_account_id, actor_api_key_id, action, outcome, target, detail, ip, user_agent, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		event.Id, event.TenantId, event.ActorUserAccountId, event.ActorApiKeyId, event.Action, event.Outcome, event.Target, event.Detail, event.Ip, event.UserAgent, event.CreatedAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert audit event.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted audit event.")
+	return nil
+}
+
+// SelectEvents selects the newest events of a tenant first, optionally narrowed by actor and time range.
+func (r *AuditRepositoryImpl) SelectEvents(filter AuditEventFilter) ([]AuditEvent, error) {
+	logger.Get().Debug("Select audit events.", zap.String("TenantId", filter.TenantId))
+
+	conditions := []string{"tenant_id = $1"}
+	args := []any{filter.TenantId}
+
+	if filter.ActorUserAccountId != "" {
+		args = append(args, filter.ActorUserAccountId)
+		conditions = append(conditions, fmt.Sprintf("actor_user_account_id = $%d", len(args)))
+	}
+
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(
+		"SELECT id, tenant_id, actor_user_account_id, actor_api_key_id, action, outcome, target, detail, ip, user_agent, created_at FROM audit.audit_event WHERE %s ORDER BY created_at DESC LIMIT $%d",
+		strings.Join(conditions, " AND "), len(args),
+	)
+
+	tx, err := database.BeginTenantTx(r.DB, filter.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Get().Warn("Failed to select audit events.")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var events []AuditEvent
+	for rows.Next() {
+		var event AuditEvent
+		err := rows.Scan(&event.Id, &event.TenantId, &event.ActorUserAccountId, &event.ActorApiKeyId, &event.Action, &event.Outcome, &event.Target, &event.Detail, &event.Ip, &event.UserAgent, &event.CreatedAt)
+		if err != nil {
+			logger.Get().Warn("Failed to scan row.")
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
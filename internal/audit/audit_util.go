@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/logger"
+	"github.com/ccthomas/gridiron/pkg/myhttp"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Record writes the event to the audit log. The ip address and user agent are taken
+// from the request, and the actor and tenant from the request context when the event
+// does not set them. A failure to record is logged and never fails the request.
+func Record(repository AuditRepository, r *http.Request, event AuditEvent) {
+	event.Id = uuid.New().String()
+	event.CreatedAt = time.Now().UTC()
+	event.Ip = myhttp.ClientIp(r)
+	event.UserAgent = r.UserAgent()
+
+	if ctx, ok := auth.FromContext(r.Context()); ok && event.ActorUserAccountId == nil {
+		event.ActorUserAccountId = &ctx.UserId
+		if ctx.ApiKeyId != "" {
+			event.ActorApiKeyId = &ctx.ApiKeyId
+		}
+	}
+
+	if tenantId, ok := auth.TenantIdFromContext(r.Context()); ok && event.TenantId == nil {
+		event.TenantId = &tenantId
+	}
+
+	err := repository.InsertEvent(event)
+	if err != nil {
+		logger.Get().Error("Failed to record audit event.", zap.String("Action", string(event.Action)), zap.Error(err))
+	}
+}
+
+// String returns a pointer to s, or nil when s is empty, for the optional event fields.
+func String(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
@@ -1,11 +1,57 @@
 package team
 
-import "github.com/ccthomas/gridiron/pkg/rabbitmq"
+import (
+	"errors"
+
+	"github.com/ccthomas/gridiron/internal/tenant"
+	"github.com/ccthomas/gridiron/pkg/league"
+	"github.com/ccthomas/gridiron/pkg/rabbitmq"
+)
+
+// Constants
+
+const (
+	TeamUpdatedMessageKey = "Team Updated"
+	TeamDeletedMessageKey = "Team Deleted"
+)
+
+// Errors
+
+var (
+	// ErrVersionConflict is returned when a team was changed since the version the caller read.
+	ErrVersionConflict = errors.New("team version conflict")
+	// ErrAbbreviationTaken is returned when another team of the tenant uses the abbreviation.
+	ErrAbbreviationTaken = errors.New("team abbreviation is taken")
+)
 
 // Data Transfer Objects
 
+// CreateNewTeamDTO creates a team. Only the name is required, and teams are active unless Active is false.
 type CreateNewTeamDTO struct {
-	Name string `json:"name"`
+	Name           string `json:"name"`
+	Abbreviation   string `json:"abbreviation"`
+	Location       string `json:"location"`
+	Nickname       string `json:"nickname"`
+	PrimaryColor   string `json:"primary_color"`
+	SecondaryColor string `json:"secondary_color"`
+	Venue          string `json:"venue"`
+	FoundedYear    *int   `json:"founded_year"`
+	Active         *bool  `json:"active"`
+}
+
+// UpdateTeamDTO changes the fields that are set. An empty string or a founding year of 0 clears
+// the field. Version must match the team's current version.
+type UpdateTeamDTO struct {
+	Name           *string `json:"name"`
+	Abbreviation   *string `json:"abbreviation"`
+	Location       *string `json:"location"`
+	Nickname       *string `json:"nickname"`
+	PrimaryColor   *string `json:"primary_color"`
+	SecondaryColor *string `json:"secondary_color"`
+	Venue          *string `json:"venue"`
+	FoundedYear    *int    `json:"founded_year"`
+	Active         *bool   `json:"active"`
+	Version        int     `json:"version"`
 }
 
 type TeamGetAllDTO struct {
@@ -15,20 +61,43 @@ type TeamGetAllDTO struct {
 
 // Entities
 
+// Team is a team within a tenant. Abbreviation is unique within the tenant when set, and
+// Version is incremented on every update.
 type Team struct {
-	Id       string `json:"id"`
-	TenantId string `json:"tenant_id"`
-	Name     string `json:"name"`
+	Id             string `json:"id"`
+	TenantId       string `json:"tenant_id"`
+	Name           string `json:"name"`
+	Abbreviation   string `json:"abbreviation"`
+	Location       string `json:"location"`
+	Nickname       string `json:"nickname"`
+	PrimaryColor   string `json:"primary_color"`
+	SecondaryColor string `json:"secondary_color"`
+	Venue          string `json:"venue"`
+	FoundedYear    *int   `json:"founded_year"`
+	Active         bool   `json:"active"`
+	Version        int    `json:"version"`
+}
+
+// newTenantMessage is the data of a New Tenant message read from the tenant exchange.
+type newTenantMessage struct {
+	Id     string          `json:"id"`
+	Name   string          `json:"name"`
+	League league.Template `json:"league"`
 }
 
 // Interfaces
 
 type TeamHandlers struct {
-	RabbitMqRouter *rabbitmq.RabbitMqRouter
-	TeamRepository TeamRepository
+	RabbitMqRouter   *rabbitmq.RabbitMqRouter
+	TeamRepository   TeamRepository
+	TenantRepository tenant.TenantRepository
+	QuotaPolicy      tenant.QuotaPolicy
 }
 
 type TeamRepository interface {
 	InsertTeams(teams []Team) error
 	SelectAllTeamsByTenant(tenantId string) ([]Team, error)
+	SelectTeam(tenantId string, id string) (*Team, error)
+	UpdateTeam(team Team) (*Team, error)
+	DeleteTeam(tenantId string, id string) (*Team, error)
 }
@@ -1,104 +0,0 @@
-package team
-
-type TeamConfiguration struct {
-	Name string
-}
-
-var nflConfig = []TeamConfiguration{
-	{
-		Name: "Arizona Cardinals",
-	},
-	{
-		Name: "Atlanta Falcons",
-	},
-	{
-		Name: "Baltimore Ravens",
-	},
-	{
-		Name: "Buffalo Bills",
-	},
-	{
-		Name: "Carolina Panthers",
-	},
-	{
-		Name: "Chicago Bears",
-	},
-	{
-		Name: "Cincinnati Bengals",
-	},
-	{
-		Name: "Cleveland Browns",
-	},
-	{
-		Name: "Dallas Cowboys",
-	},
-	{
-		Name: "Denver Broncos",
-	},
-	{
-		Name: "Detroit Lions",
-	},
-	{
-		Name: "Green Bay Packers",
-	},
-	{
-		Name: "Houston Texans",
-	},
-	{
-		Name: "Indianapolis Colts",
-	},
-	{
-		Name: "Jacksonville Jaguars",
-	},
-	{
-		Name: "Kansas City Chiefs",
-	},
-	{
-		Name: "Las Vegas Raiders",
-	},
-	{
-		Name: "Los Angeles Chargers",
-	},
-	{
-		Name: "Los Angeles Rams",
-	},
-	{
-		Name: "Miami Dolphins",
-	},
-	{
-		Name: "Minnesota Vikings",
-	},
-	{
-		Name: "New England Patriots",
-	},
-	{
-		Name: "New Orleans Saints",
-	},
-	{
-		Name: "New York Giants",
-	},
-	{
-		Name: "New York Jets",
-	},
-	{
-		Name: "Philadelphia Eagles",
-	},
-	{
-		Name: "Pittsburgh Steelers",
-	},
-	{
-		Name: "San Francisco 49ers",
-	},
-	{
-		Name: "Seattle Seahawks",
-	},
-	{
-		Name: "Tampa Bay Buccaneers",
-	},
-	{
-		Name: "Tennessee Titans",
-	},
-	{
-		Name: "Washington Football Team",
-	},
-}
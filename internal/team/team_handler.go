@@ -1,46 +1,47 @@
 package team
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"os"
 
+	"github.com/ccthomas/gridiron/internal/tenant"
 	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/league"
 	"github.com/ccthomas/gridiron/pkg/logger"
 	"github.com/ccthomas/gridiron/pkg/myhttp"
 	"github.com/ccthomas/gridiron/pkg/rabbitmq"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
-func NewHandlers(rmq *rabbitmq.RabbitMqRouter, teamRepository TeamRepository) *TeamHandlers {
+func NewHandlers(rmq *rabbitmq.RabbitMqRouter, teamRepository TeamRepository, tenantRepository tenant.TenantRepository, quotaPolicy tenant.QuotaPolicy) *TeamHandlers {
 	logger.Get().Debug("Constructing tenant handlers")
 	return &TeamHandlers{
-		RabbitMqRouter: rmq,
-		TeamRepository: teamRepository,
+		RabbitMqRouter:   rmq,
+		TeamRepository:   teamRepository,
+		TenantRepository: tenantRepository,
+		QuotaPolicy:      quotaPolicy,
 	}
 }
 
 func (h *TeamHandlers) CreateNewTeamHandler(w http.ResponseWriter, r *http.Request) {
 	logger.Get().Info("Create New Team Handler hit.")
 
-	ctx, err := auth.GetAuthorizerContext(r)
-	if err != nil {
-		logger.Logger.Debug("Failed to get authorizer context from request")
-		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
-		return
-	}
-
 	logger.Get().Debug("Get tenant id.")
-	tenantId := r.Header.Get("x-tenant-id")
-	if ctx.TenantAccess[tenantId] != auth.Owner {
-		logger.Logger.Debug("User does not have access to tenant.")
-		myhttp.WriteError(w, http.StatusUnauthorized, "User is unauthorized to access tenant.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
 		return
 	}
 
 	var dto CreateNewTeamDTO
 	logger.Get().Debug("Decode new team data.")
-	err = json.NewDecoder(r.Body).Decode(&dto)
+	err := json.NewDecoder(r.Body).Decode(&dto)
 	if err != nil {
 		logger.Get().Error("Failed to parse body.", zap.Error(err))
 		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
@@ -48,12 +49,56 @@ func (h *TeamHandlers) CreateNewTeamHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	t := Team{
-		Id:       uuid.New().String(),
-		TenantId: tenantId,
-		Name:     dto.Name,
+		Id:             uuid.New().String(),
+		TenantId:       tenantId,
+		Name:           dto.Name,
+		Abbreviation:   dto.Abbreviation,
+		Location:       dto.Location,
+		Nickname:       dto.Nickname,
+		PrimaryColor:   dto.PrimaryColor,
+		SecondaryColor: dto.SecondaryColor,
+		Venue:          dto.Venue,
+		FoundedYear:    dto.FoundedYear,
+		Active:         dto.Active == nil || *dto.Active,
+		Version:        1,
+	}
+
+	t.Normalize()
+	err = t.Validate()
+	if err != nil {
+		logger.Get().Warn("Invalid team.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logger.Get().Debug("Check tenant quota.")
+	usage, err := h.TenantRepository.SelectUsage(tenantId)
+	if err != nil {
+		logger.Get().Error("Failed to select tenant usage.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	quota := h.QuotaPolicy.QuotaFor(h.TenantRepository, tenantId)
+	if !tenant.WithinLimit(quota.MaxTeams, usage.Teams, 1) {
+		logger.Get().Warn("Tenant reached team quota.", zap.String("TenantId", tenantId))
+		myhttp.WriteError(w, http.StatusForbidden, "Team quota exceeded.")
+		return
+	}
+
+	if !tenant.WithinLimit(quota.MaxStorageBytes, usage.StorageBytes, t.storageBytes()) {
+		logger.Get().Warn("Tenant reached storage quota.", zap.String("TenantId", tenantId))
+		myhttp.WriteError(w, http.StatusForbidden, "Storage quota exceeded.")
+		return
 	}
 
 	err = h.TeamRepository.InsertTeams([]Team{t})
+	if errors.Is(err, ErrAbbreviationTaken) {
+		logger.Get().Warn("Team abbreviation is taken.", zap.String("Abbreviation", t.Abbreviation))
+		myhttp.WriteError(w, http.StatusConflict, "Abbreviation is already used by another team.")
+		return
+	}
+
 	if err != nil {
 		logger.Get().Error("Failed to insert team.", zap.Error(err))
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
@@ -74,7 +119,12 @@ func (h *TeamHandlers) GetAllTeamsHandler(w http.ResponseWriter, r *http.Request
 	logger.Get().Info("Get All Teams Handler hit.")
 
 	logger.Get().Debug("Get tenant id.")
-	tenantId := r.Header.Get("x-tenant-id")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
 
 	teams, err := h.TeamRepository.SelectAllTeamsByTenant(tenantId)
 	if err != nil {
@@ -105,27 +155,251 @@ func (h *TeamHandlers) GetAllTeamsHandler(w http.ResponseWriter, r *http.Request
 	w.Write(jsonResponse)
 }
 
+func (h *TeamHandlers) GetTeamHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get Team Handler hit.")
+
+	logger.Get().Debug("Get tenant id.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	t, err := h.TeamRepository.SelectTeam(tenantId, mux.Vars(r)["teamId"])
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Get().Warn("Team not found.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Team not found.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to select team.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(t)
+	if err != nil {
+		logger.Get().Error("Failed to encode team.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// UpdateTeamHandler applies the set fields of the request to the team, provided the request's
+// version matches the stored one, and publishes a "Team Updated" message.
+func (h *TeamHandlers) UpdateTeamHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Update Team Handler hit.")
+
+	logger.Get().Debug("Get tenant id.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto UpdateTeamDTO
+	logger.Get().Debug("Decode team data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		logger.Get().Warn("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	if dto.Version < 1 {
+		logger.Get().Warn("Team version not provided.")
+		myhttp.WriteError(w, http.StatusBadRequest, "Version is required.")
+		return
+	}
+
+	t, err := h.TeamRepository.SelectTeam(tenantId, mux.Vars(r)["teamId"])
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Get().Warn("Team not found.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Team not found.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to select team.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	previousBytes := t.storageBytes()
+	dto.applyTo(t)
+
+	t.Normalize()
+	err = t.Validate()
+	if err != nil {
+		logger.Get().Warn("Invalid team.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if growth := t.storageBytes() - previousBytes; growth > 0 {
+		usage, err := h.TenantRepository.SelectUsage(tenantId)
+		if err != nil {
+			logger.Get().Error("Failed to select tenant usage.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+
+		quota := h.QuotaPolicy.QuotaFor(h.TenantRepository, tenantId)
+		if !tenant.WithinLimit(quota.MaxStorageBytes, usage.StorageBytes, growth) {
+			logger.Get().Warn("Tenant reached storage quota.", zap.String("TenantId", tenantId))
+			myhttp.WriteError(w, http.StatusForbidden, "Storage quota exceeded.")
+			return
+		}
+	}
+
+	t.Version = dto.Version
+	updated, err := h.TeamRepository.UpdateTeam(*t)
+	if errors.Is(err, ErrVersionConflict) {
+		logger.Get().Warn("Team was modified concurrently.", zap.String("TeamId", t.Id))
+		myhttp.WriteError(w, http.StatusConflict, "Team version is out of date.")
+		return
+	}
+
+	if errors.Is(err, ErrAbbreviationTaken) {
+		logger.Get().Warn("Team abbreviation is taken.", zap.String("Abbreviation", t.Abbreviation))
+		myhttp.WriteError(w, http.StatusConflict, "Abbreviation is already used by another team.")
+		return
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Get().Warn("Team not found.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Team not found.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to update team.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.publishTeamMessage(TeamUpdatedMessageKey, *updated)
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(updated)
+	if err != nil {
+		logger.Get().Error("Failed to encode team.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// DeleteTeamHandler deletes the team and publishes a "Team Deleted" message.
+func (h *TeamHandlers) DeleteTeamHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Delete Team Handler hit.")
+
+	logger.Get().Debug("Get tenant id.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	t, err := h.TeamRepository.DeleteTeam(tenantId, mux.Vars(r)["teamId"])
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Get().Warn("Team not found.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Team not found.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to delete team.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.publishTeamMessage(TeamDeletedMessageKey, *t)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publishTeamMessage publishes the team to the team exchange. A failed publish is logged,
+// the change it describes is already stored.
+func (h *TeamHandlers) publishTeamMessage(key string, t Team) {
+	logger.Get().Debug("Publish team message.", zap.String("Key", key))
+	err := h.RabbitMqRouter.PublishMessage(os.Getenv("RABBITMQ_EXCHANGE_TEAM"), key, []rabbitmq.RabbitMqBody{
+		{
+			DataVersion: "1.0.0",
+			Data:        t,
+		},
+	})
+	if err != nil {
+		logger.Get().Error("Failed to publish team message.", zap.String("Key", key), zap.Error(err))
+	}
+}
+
+func (h *TeamHandlers) GetLeagueTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get League Templates Handler hit.")
+
+	templates := league.All()
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(league.TemplateGetAllDTO{
+		Count: len(templates),
+		Data:  templates,
+	})
+	if err != nil {
+		logger.Get().Error("Failed to encode league templates.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
 func (h *TeamHandlers) ProcessNewTenantMessageHandler(body rabbitmq.RabbitMqBody) {
 	logger.Get().Info("Process New Tenant Message handler", zap.Any("Body", body))
 
-	if body.DataVersion != "1.0.0" {
-		logger.Get().Fatal("Only supports 1.0.0 of data event.")
+	logger.Get().Debug("Decode new tenant message data.")
+	data, err := json.Marshal(body.Data)
+	if err != nil {
+		logger.Get().Error("Failed to marshal message data.", zap.Error(err))
+		return
 	}
 
-	tenantId := body.Data.(map[string]interface{})["id"].(string)
+	var message newTenantMessage
+	err = json.Unmarshal(data, &message)
+	if err != nil {
+		logger.Get().Error("Failed to unmarshal message data.", zap.Error(err))
+		return
+	}
 
-	teams := []Team{}
-	for _, config := range nflConfig {
-		t := Team{
-			Id:       uuid.New().String(),
-			TenantId: tenantId,
-			Name:     config.Name,
+	switch body.DataVersion {
+	case "1.0.0":
+		logger.Get().Debug("Message predates league templates, seed default template.")
+		message.League, err = league.Lookup(league.DefaultTemplate)
+		if err != nil {
+			logger.Get().Error("Failed to look up default league template.", zap.Error(err))
+			return
 		}
+	case "1.1.0":
+	default:
+		logger.Get().Error("Unsupported data version of new tenant message.", zap.String("DataVersion", body.DataVersion))
+		return
+	}
 
-		teams = append(teams, t)
+	teams := []Team{}
+	for _, definition := range message.League.Teams {
+		teams = append(teams, teamFromDefinition(uuid.New().String(), message.Id, definition))
+	}
+
+	if len(teams) == 0 {
+		logger.Get().Debug("League template has no teams to insert.", zap.String("LeagueTemplate", message.League.Name))
+		return
 	}
 
-	err := h.TeamRepository.InsertTeams(teams)
+	err = h.TeamRepository.InsertTeams(teams)
 	if err != nil {
 		logger.Get().Error("Failed to insert teams.", zap.Error(err))
 		return
@@ -2,8 +2,12 @@ package team
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 
+	"github.com/ccthomas/gridiron/pkg/database"
 	"github.com/ccthomas/gridiron/pkg/logger"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -18,12 +22,22 @@ func NewTeamRepository(db *sql.DB) *TeamRepositoryImpl {
 	}
 }
 
+// InsertTeams inserts teams of a single tenant in one tenant transaction.
 func (r *TeamRepositoryImpl) InsertTeams(teams []Team) error {
 	logger.Get().Debug("Insert teams.")
+	if len(teams) == 0 {
+		return nil
+	}
+
+	tenantId := teams[0].TenantId
+	for _, team := range teams {
+		if team.TenantId != tenantId {
+			return fmt.Errorf("teams must belong to one tenant")
+		}
+	}
 
-	tx, err := r.DB.Begin()
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
 	if err != nil {
-		logger.Get().Error("Failed to start transaction:", zap.Error(err))
 		return err
 	}
 
@@ -35,10 +49,16 @@ func (r *TeamRepositoryImpl) InsertTeams(teams []Team) error {
 	}()
 
 	for _, team := range teams {
-		_, err := tx.Exec("INSERT INTO team.team (id, tenant_id, name) VALUES ($1, $2, $3)", team.Id, team.TenantId, team.Name)
+		_, err := tx.Exec(
+			"INSERT INTO team.team (id, tenant_id, name, abbreviation, location, nickname, primary_color, secondary_color, venue, founded_year, active) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11)",
+			team.Id, team.TenantId, team.Name, team.Abbreviation, team.Location, team.Nickname, team.PrimaryColor, team.SecondaryColor, team.Venue, team.FoundedYear, team.Active,
+		)
 		if err != nil {
 			logger.Get().Warn("Failed to insert team", zap.Error(err))
 			tx.Rollback()
+			if isAbbreviationTaken(err) {
+				return ErrAbbreviationTaken
+			}
 			return err
 		}
 	}
@@ -55,8 +75,14 @@ func (r *TeamRepositoryImpl) InsertTeams(teams []Team) error {
 
 func (r *TeamRepositoryImpl) SelectAllTeamsByTenant(tenantId string) ([]Team, error) {
 	logger.Get().Debug("Select team by tenant id.")
-	rows, err := r.DB.Query("SELECT id, tenant_id, name FROM team.team WHERE tenant_id = $1 ORDER BY name ASC;", tenantId)
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return nil, err
+	}
 
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, tenant_id, name, COALESCE(abbreviation, ''), location, nickname, primary_color, secondary_color, venue, founded_year, active, version FROM team.team WHERE tenant_id = $1 ORDER BY name ASC;", tenantId)
 	if err != nil {
 		logger.Get().Warn("Failed to select team by tenant id.")
 		return nil, err
@@ -67,17 +93,135 @@ func (r *TeamRepositoryImpl) SelectAllTeamsByTenant(tenantId string) ([]Team, er
 	logger.Get().Debug("Start scanning rows.")
 	var teams []Team
 	for rows.Next() {
-		var t Team
 		logger.Get().Debug("Scan next row.")
-		if err := rows.Scan(&t.Id, &t.TenantId, &t.Name); err != nil {
+		t, err := scanTeam(rows)
+		if err != nil {
 			logger.Get().Warn("Failed to scan row.")
 			return nil, err
 		}
 
 		logger.Get().Debug("Add team to teams array.")
-		teams = append(teams, t)
+		teams = append(teams, *t)
 	}
 
 	logger.Get().Debug("Return teams.")
-	return teams, nil
+	return teams, rows.Err()
+}
+
+func (r *TeamRepositoryImpl) SelectTeam(tenantId string, id string) (*Team, error) {
+	logger.Get().Debug("Select team by id.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	t, err := scanTeam(tx.QueryRow("SELECT id, tenant_id, name, COALESCE(abbreviation, ''), location, nickname, primary_color, secondary_color, venue, founded_year, active, version FROM team.team WHERE id = $1 AND tenant_id = $2", id, tenantId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Team not found.")
+			return nil, fmt.Errorf("team not found: %w", sql.ErrNoRows)
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found team.")
+	return t, nil
+}
+
+// UpdateTeam updates the team when its stored version matches team.Version and increments the version.
+// ErrVersionConflict is returned when the team exists with another version.
+func (r *TeamRepositoryImpl) UpdateTeam(team Team) (*Team, error) {
+	logger.Get().Debug("Update team.")
+	tx, err := database.BeginTenantTx(r.DB, team.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	t, err := scanTeam(tx.QueryRow(
+		"UPDATE team.team SET name = $3, abbreviation = NULLIF($4, ''), location = $5, nickname = $6, primary_color = $7, secondary_color = $8, venue = $9, founded_year = $10, active = $11, version = version + 1 WHERE id = $1 AND tenant_id = $2 AND version = $12 RETURNING id, tenant_id, name, COALESCE(abbreviation, ''), location, nickname, primary_color, secondary_color, venue, founded_year, active, version",
+		team.Id, team.TenantId, team.Name, team.Abbreviation, team.Location, team.Nickname, team.PrimaryColor, team.SecondaryColor, team.Venue, team.FoundedYear, team.Active, team.Version,
+	))
+	if err == sql.ErrNoRows {
+		var exists bool
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM team.team WHERE id = $1 AND tenant_id = $2)", team.Id, team.TenantId).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			logger.Get().Debug("Team version conflict.")
+			return nil, ErrVersionConflict
+		}
+
+		logger.Get().Debug("Team not found.")
+		return nil, fmt.Errorf("team not found: %w", sql.ErrNoRows)
+	}
+
+	if err != nil {
+		logger.Get().Warn("Failed to update team.", zap.Error(err))
+		if isAbbreviationTaken(err) {
+			return nil, ErrAbbreviationTaken
+		}
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Get().Debug("Successfully updated team.")
+	return t, nil
+}
+
+func (r *TeamRepositoryImpl) DeleteTeam(tenantId string, id string) (*Team, error) {
+	logger.Get().Debug("Delete team.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	t, err := scanTeam(tx.QueryRow("DELETE FROM team.team WHERE id = $1 AND tenant_id = $2 RETURNING id, tenant_id, name, COALESCE(abbreviation, ''), location, nickname, primary_color, secondary_color, venue, founded_year, active, version", id, tenantId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Team not found.")
+			return nil, fmt.Errorf("team not found: %w", sql.ErrNoRows)
+		}
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Get().Debug("Successfully deleted team.")
+	return t, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTeam(row rowScanner) (*Team, error) {
+	var t Team
+	err := row.Scan(&t.Id, &t.TenantId, &t.Name, &t.Abbreviation, &t.Location, &t.Nickname, &t.PrimaryColor, &t.SecondaryColor, &t.Venue, &t.FoundedYear, &t.Active, &t.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func isAbbreviationTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Constraint == "team_tenant_abbreviation_key"
 }
@@ -0,0 +1,107 @@
+package team
+
+import (
+	"strings"
+
+	"github.com/ccthomas/gridiron/pkg/league"
+)
+
+// Normalize trims the text fields of the team and upper cases its abbreviation.
+func (t *Team) Normalize() {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Abbreviation = strings.ToUpper(strings.TrimSpace(t.Abbreviation))
+	t.Location = strings.TrimSpace(t.Location)
+	t.Nickname = strings.TrimSpace(t.Nickname)
+	t.PrimaryColor = strings.TrimSpace(t.PrimaryColor)
+	t.SecondaryColor = strings.TrimSpace(t.SecondaryColor)
+	t.Venue = strings.TrimSpace(t.Venue)
+}
+
+// Validate checks a normalized team. The error message is suitable for the api response.
+func (t Team) Validate() error {
+	return league.ValidateTeamMetadata(league.TeamMetadata{
+		Name:         t.Name,
+		Abbreviation: t.Abbreviation,
+		Location:     t.Location,
+		Nickname:     t.Nickname,
+		Venue:        t.Venue,
+		Colors:       []string{t.PrimaryColor, t.SecondaryColor},
+		FoundedYear:  t.FoundedYear,
+	})
+}
+
+// applyTo copies the fields set in the request onto the team. A founding year of 0 clears it.
+func (dto UpdateTeamDTO) applyTo(t *Team) {
+	if dto.Name != nil {
+		t.Name = *dto.Name
+	}
+
+	if dto.Abbreviation != nil {
+		t.Abbreviation = *dto.Abbreviation
+	}
+
+	if dto.Location != nil {
+		t.Location = *dto.Location
+	}
+
+	if dto.Nickname != nil {
+		t.Nickname = *dto.Nickname
+	}
+
+	if dto.PrimaryColor != nil {
+		t.PrimaryColor = *dto.PrimaryColor
+	}
+
+	if dto.SecondaryColor != nil {
+		t.SecondaryColor = *dto.SecondaryColor
+	}
+
+	if dto.Venue != nil {
+		t.Venue = *dto.Venue
+	}
+
+	if dto.FoundedYear != nil {
+		t.FoundedYear = dto.FoundedYear
+		if *dto.FoundedYear == 0 {
+			t.FoundedYear = nil
+		}
+	}
+
+	if dto.Active != nil {
+		t.Active = *dto.Active
+	}
+}
+
+// storageBytes is the size a team counts against the tenant's storage quota: the bytes of its
+// text fields. TenantRepository.SelectUsage and the archive import and clone sum the same columns.
+func (t Team) storageBytes() int64 {
+	return int64(len(t.Name) + len(t.Abbreviation) + len(t.Location) + len(t.Nickname) + len(t.PrimaryColor) + len(t.SecondaryColor) + len(t.Venue))
+}
+
+// teamFromDefinition builds an active team of the tenant from a league template team.
+// The first two colors of the definition become the primary and secondary color.
+func teamFromDefinition(id string, tenantId string, definition league.TeamDefinition) Team {
+	t := Team{
+		Id:           id,
+		TenantId:     tenantId,
+		Name:         definition.Name,
+		Abbreviation: definition.Abbreviation,
+		Location:     definition.Location,
+		Nickname:     definition.Nickname,
+		Venue:        definition.Venue,
+		FoundedYear:  definition.FoundedYear,
+		Active:       true,
+		Version:      1,
+	}
+
+	if len(definition.Colors) > 0 {
+		t.PrimaryColor = definition.Colors[0]
+	}
+
+	if len(definition.Colors) > 1 {
+		t.SecondaryColor = definition.Colors[1]
+	}
+
+	t.Normalize()
+	return t
+}
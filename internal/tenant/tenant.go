@@ -1,10 +1,80 @@
 package tenant
 
 import (
+	"errors"
+	"time"
+
+	"github.com/ccthomas/gridiron/internal/audit"
 	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/league"
 	"github.com/ccthomas/gridiron/pkg/rabbitmq"
 )
 
+// Constants
+
+const (
+	InvitationDuration        = 7 * 24 * time.Hour
+	OwnershipTransferDuration = 7 * 24 * time.Hour
+)
+
+const MaxTenantNameLength = 255
+
+// Message keys published on the tenant exchange.
+const (
+	NewTenantMessageKey            = "New Tenant"
+	TenantDeletedMessageKey        = "Tenant Deleted"
+	TenantRestoredMessageKey       = "Tenant Restored"
+	TenantPurgedMessageKey         = "Tenant Purged"
+	TenantCloneRequestedMessageKey = "Tenant Clone Requested"
+)
+
+// NewTenantMessageVersion added the league template to the New Tenant message.
+const NewTenantMessageVersion = "1.1.0"
+
+// ArchiveSchemaVersion is the version of the tenant archive written by export. Import
+// accepts archives with the same major version. 1.1.0 added the team metadata.
+const (
+	ArchiveSchemaVersion = "1.1.0"
+	MaxArchiveBytes      = 10 << 20
+)
+
+// ApiKeyPrefix marks Gridiron api keys so they are easy to recognize in logs and secret scanners.
+const ApiKeyPrefix = "grd_"
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "PENDING"
+	InvitationAccepted InvitationStatus = "ACCEPTED"
+	InvitationDeclined InvitationStatus = "DECLINED"
+)
+
+type OwnershipTransferStatus string
+
+const (
+	TransferPending   OwnershipTransferStatus = "PENDING"
+	TransferAccepted  OwnershipTransferStatus = "ACCEPTED"
+	TransferDeclined  OwnershipTransferStatus = "DECLINED"
+	TransferCancelled OwnershipTransferStatus = "CANCELLED"
+)
+
+type TenantCloneStatus string
+
+const (
+	ClonePending   TenantCloneStatus = "PENDING"
+	CloneRunning   TenantCloneStatus = "RUNNING"
+	CloneCompleted TenantCloneStatus = "COMPLETED"
+	CloneFailed    TenantCloneStatus = "FAILED"
+)
+
+// Errors
+
+var (
+	ErrOwnershipTransferInvalid = errors.New("ownership transfer is no longer valid")
+	ErrArchiveVersion           = errors.New("archive schema version is not supported")
+	ErrArchiveInvalid           = errors.New("archive is invalid")
+)
+
 // Data Transfer Objects
 
 type TenantGetAllDTO struct {
@@ -12,11 +82,183 @@ type TenantGetAllDTO struct {
 	Data  []Tenant `json:"data"`
 }
 
+// CreateTenantDTO is the optional body of a new tenant request. CustomLeague is
+// only read when LeagueTemplate is league.Custom.
+type CreateTenantDTO struct {
+	LeagueTemplate string           `json:"league_template"`
+	CustomLeague   *league.Template `json:"custom_league"`
+}
+
+type UpdateTenantDTO struct {
+	Name string `json:"name"`
+}
+
+// UsageCounterDTO pairs the usage of a resource with its limit. A limit of 0 is unlimited.
+type UsageCounterDTO struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
+
+type TenantUsageDTO struct {
+	TenantId      string          `json:"tenant_id"`
+	Teams         UsageCounterDTO `json:"teams"`
+	Players       UsageCounterDTO `json:"players"`
+	Members       UsageCounterDTO `json:"members"`
+	ApiCallsToday UsageCounterDTO `json:"api_calls_today"`
+	StorageBytes  UsageCounterDTO `json:"storage_bytes"`
+}
+
+// TenantArchiveDTO is everything stored in a tenant, written by export and read by import.
+// Ids are only used to relate records within the archive; import assigns fresh ids.
+type TenantArchiveDTO struct {
+	SchemaVersion string          `json:"schema_version"`
+	ExportedAt    time.Time       `json:"exported_at"`
+	Tenant        Tenant          `json:"tenant"`
+	Members       []ArchiveMember `json:"members"`
+	Teams         []ArchiveTeam   `json:"teams"`
+}
+
+// TenantImportDTO is the result of an import. Invitations counts the archived members the
+// caller invited, including any whose user account no longer exists.
+type TenantImportDTO struct {
+	Tenant      Tenant `json:"tenant"`
+	Teams       int    `json:"teams"`
+	Invitations int    `json:"invitations"`
+}
+
+// CreateTenantCloneDTO is the optional body of a clone request. The name defaults to the
+// source tenant's name followed by " (copy)".
+type CreateTenantCloneDTO struct {
+	Name string `json:"name"`
+}
+
+type TenantCloneRequestedMessage struct {
+	CloneId string `json:"clone_id"`
+}
+
+type CreateInvitationDTO struct {
+	Username    string           `json:"username"`
+	AccessLevel auth.AccessLevel `json:"access_level"`
+}
+
+type TenantInvitationGetAllDTO struct {
+	Count int                `json:"count"`
+	Data  []TenantInvitation `json:"data"`
+}
+
+type UpdateMemberDTO struct {
+	AccessLevel auth.AccessLevel `json:"access_level"`
+}
+
+type CreateOwnershipTransferDTO struct {
+	UserAccountId string `json:"user_account_id"`
+}
+
+type OwnershipTransferGetAllDTO struct {
+	Count int                 `json:"count"`
+	Data  []OwnershipTransfer `json:"data"`
+}
+
+type TenantMemberGetAllDTO struct {
+	Count int            `json:"count"`
+	Data  []TenantMember `json:"data"`
+}
+
+type CreateApiKeyDTO struct {
+	Name      string            `json:"name"`
+	Scopes    []auth.Permission `json:"scopes"`
+	ExpiresAt *time.Time        `json:"expires_at"`
+}
+
+type ApiKeyCreatedDTO struct {
+	ApiKey
+	Key string `json:"key"`
+}
+
+type ApiKeyGetAllDTO struct {
+	Count int      `json:"count"`
+	Data  []ApiKey `json:"data"`
+}
+
 // Entities
 
+// Tenant is a league. DeletedAt is set while the tenant is soft deleted and awaiting purge.
 type Tenant struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// TenantQuota holds the limits of a tenant. A limit of 0 is unlimited.
+type TenantQuota struct {
+	MaxTeams          int64 `json:"max_teams"`
+	MaxPlayers        int64 `json:"max_players"`
+	MaxMembers        int64 `json:"max_members"`
+	MaxApiCallsPerDay int64 `json:"max_api_calls_per_day"`
+	MaxStorageBytes   int64 `json:"max_storage_bytes"`
+}
+
+// TenantUsage is what a tenant currently consumes. Players are not stored yet and count as 0.
+type TenantUsage struct {
+	Teams         int64
+	Players       int64
+	Members       int64
+	ApiCallsToday int64
+	StorageBytes  int64
+}
+
+// RetentionPolicy decides how long deleted tenants can be restored and how often the purge job
+// hard deletes the tenants whose retention has passed.
+type RetentionPolicy struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// QuotaPolicy holds the configured limits. Tenant is used for every tenant without a stored override.
+type QuotaPolicy struct {
+	MaxTenantsPerUser int64
+	Tenant            TenantQuota
+}
+
+type ArchiveMember struct {
+	UserAccountId string           `json:"user_account_id"`
+	AccessLevel   auth.AccessLevel `json:"access_level"`
+}
+
+// ArchiveTeam is a team in a tenant archive. Active is missing from 1.0.0 archives, and such
+// teams are imported as active.
+type ArchiveTeam struct {
+	Id             string `json:"id"`
+	Name           string `json:"name"`
+	Abbreviation   string `json:"abbreviation,omitempty"`
+	Location       string `json:"location,omitempty"`
+	Nickname       string `json:"nickname,omitempty"`
+	PrimaryColor   string `json:"primary_color,omitempty"`
+	SecondaryColor string `json:"secondary_color,omitempty"`
+	Venue          string `json:"venue,omitempty"`
+	FoundedYear    *int   `json:"founded_year,omitempty"`
+	Active         *bool  `json:"active,omitempty"`
+}
+
+// TenantClone tracks an asynchronous copy of a tenant. TargetTenantId is reserved when the
+// clone is requested and the tenant exists once Status is CloneCompleted.
+type TenantClone struct {
+	Id             string            `json:"id"`
+	SourceTenantId string            `json:"source_tenant_id"`
+	TargetTenantId string            `json:"target_tenant_id"`
+	TargetName     string            `json:"target_name"`
+	RequestedBy    string            `json:"requested_by"`
+	Status         TenantCloneStatus `json:"status"`
+	Error          *string           `json:"error"`
+	CreatedAt      time.Time         `json:"created_at"`
+	CompletedAt    *time.Time        `json:"completed_at"`
+}
+
+// NewTenantMessage is the data of a New Tenant message. The tenant fields stay at the
+// top level so consumers of version 1.0.0 can still read them.
+type NewTenantMessage struct {
+	Tenant
+	League league.Template `json:"league"`
 }
 
 type TenantUserAccess struct {
@@ -25,16 +267,100 @@ type TenantUserAccess struct {
 	AccessLevel   auth.AccessLevel `json:"access_level"`
 }
 
+type TenantInvitation struct {
+	Id            string           `json:"id"`
+	TenantId      string           `json:"tenant_id"`
+	UserAccountId string           `json:"user_account_id"`
+	AccessLevel   auth.AccessLevel `json:"access_level"`
+	InvitedBy     string           `json:"invited_by"`
+	Status        InvitationStatus `json:"status"`
+	CreatedAt     time.Time        `json:"created_at"`
+	ExpiresAt     time.Time        `json:"expires_at"`
+}
+
+// OwnershipTransfer hands ownership of a tenant from one member to another once the
+// recipient accepts. The previous owner stays on as an admin.
+type OwnershipTransfer struct {
+	Id                string                  `json:"id"`
+	TenantId          string                  `json:"tenant_id"`
+	FromUserAccountId string                  `json:"from_user_account_id"`
+	ToUserAccountId   string                  `json:"to_user_account_id"`
+	Status            OwnershipTransferStatus `json:"status"`
+	CreatedAt         time.Time               `json:"created_at"`
+	ExpiresAt         time.Time               `json:"expires_at"`
+}
+
+type TenantMember struct {
+	UserAccountId string           `json:"user_account_id"`
+	Username      string           `json:"username"`
+	AccessLevel   auth.AccessLevel `json:"access_level"`
+}
+
+type ApiKey struct {
+	Id         string            `json:"id"`
+	TenantId   string            `json:"tenant_id"`
+	Name       string            `json:"name"`
+	KeyPrefix  string            `json:"key_prefix"`
+	KeyHash    string            `json:"-"`
+	Scopes     []auth.Permission `json:"scopes"`
+	CreatedBy  string            `json:"created_by"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ExpiresAt  *time.Time        `json:"expires_at"`
+	LastUsedAt *time.Time        `json:"last_used_at"`
+	RevokedAt  *time.Time        `json:"revoked_at"`
+}
+
 // Interfaces
 
 type TenantHandlers struct {
 	RabbitMqRouter   *rabbitmq.RabbitMqRouter
 	TenantRepository TenantRepository
+	AuditRepository  audit.AuditRepository
+	QuotaPolicy      QuotaPolicy
+	RetentionPolicy  RetentionPolicy
 }
 
 type TenantRepository interface {
 	InsertTenant(tenant Tenant) error
+	SelectTenant(id string) (*Tenant, error)
+	UpdateTenant(tenant Tenant) error
+	SoftDeleteTenant(id string) (*Tenant, error)
+	SelectDeletedTenant(id string) (*Tenant, error)
+	RestoreTenant(id string, deletedAfter time.Time) error
+	PurgeDeletedTenants(deletedBefore time.Time) ([]Tenant, error)
 	InsertUserAccess(userAccess TenantUserAccess) error
 	SelectTenantByUser(userId string) ([]Tenant, error)
+	SelectSoleOwnedTenantsByUser(userId string) ([]Tenant, error)
 	SelectTenantAccessByUser(userId string) ([]TenantUserAccess, error)
+	SelectUserAccess(tenantId string, userId string) (*TenantUserAccess, error)
+	UpdateUserAccess(userAccess TenantUserAccess) error
+	DeleteUserAccess(tenantId string, userId string) error
+	SelectMembers(tenantId string) ([]TenantMember, error)
+	SelectUserAccountIdByUsername(username string) (string, error)
+	InsertInvitation(invitation TenantInvitation) error
+	SelectInvitation(id string) (*TenantInvitation, error)
+	SelectPendingInvitationsByUser(userId string) ([]TenantInvitation, error)
+	AcceptInvitation(invitation TenantInvitation) error
+	UpdateInvitationStatus(tenantId string, id string, status InvitationStatus) error
+	InsertOwnershipTransfer(transfer OwnershipTransfer) error
+	SelectOwnershipTransfer(id string) (*OwnershipTransfer, error)
+	SelectPendingOwnershipTransfersByUser(userId string) ([]OwnershipTransfer, error)
+	AcceptOwnershipTransfer(transfer OwnershipTransfer) error
+	UpdateOwnershipTransferStatus(id string, status OwnershipTransferStatus) error
+	InsertApiKey(apiKey ApiKey) error
+	SelectApiKeysByTenant(tenantId string) ([]ApiKey, error)
+	SelectApiKeyByHash(keyHash string) (*ApiKey, error)
+	RevokeApiKey(tenantId string, id string) error
+	UpdateApiKeyLastUsed(id string) error
+	CountOwnedTenantsByUser(userId string) (int64, error)
+	SelectQuota(tenantId string) (*TenantQuota, error)
+	UpsertQuota(tenantId string, quota TenantQuota) error
+	SelectUsage(tenantId string) (*TenantUsage, error)
+	IncrementApiCalls(tenantId string) (int64, error)
+	SelectArchiveTeams(tenantId string) ([]ArchiveTeam, error)
+	InsertArchive(tenant Tenant, owner TenantUserAccess, invitations []TenantInvitation, teams []ArchiveTeam) error
+	InsertClone(clone TenantClone) error
+	SelectClone(id string) (*TenantClone, error)
+	StartClone(id string) error
+	FinishClone(id string, status TenantCloneStatus, cloneError *string) error
 }
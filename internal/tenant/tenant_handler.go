@@ -2,10 +2,17 @@ package tenant
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/ccthomas/gridiron/internal/audit"
 	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/league"
 	"github.com/ccthomas/gridiron/pkg/logger"
 	"github.com/ccthomas/gridiron/pkg/myhttp"
 	"github.com/ccthomas/gridiron/pkg/rabbitmq"
@@ -14,18 +21,21 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewHandlers(rmq *rabbitmq.RabbitMqRouter, tenantRepository TenantRepository) *TenantHandlers {
+func NewHandlers(rmq *rabbitmq.RabbitMqRouter, tenantRepository TenantRepository, auditRepository audit.AuditRepository, quotaPolicy QuotaPolicy, retentionPolicy RetentionPolicy) *TenantHandlers {
 	logger.Get().Debug("Constructing tenant handlers")
 	return &TenantHandlers{
 		RabbitMqRouter:   rmq,
 		TenantRepository: tenantRepository,
+		AuditRepository:  auditRepository,
+		QuotaPolicy:      quotaPolicy,
+		RetentionPolicy:  retentionPolicy,
 	}
 }
 
 func (h *TenantHandlers) GetAllTenantsHandler(w http.ResponseWriter, r *http.Request) {
 	logger.Get().Info("New Tenant Handler hit.")
-	ctx, err := auth.GetAuthorizerContext(r)
-	if err != nil {
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
 		logger.Logger.Debug("Failed to get authorizer context from request")
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
 		return
@@ -62,8 +72,8 @@ func (h *TenantHandlers) GetAllTenantsHandler(w http.ResponseWriter, r *http.Req
 
 func (h *TenantHandlers) NewTenantHandler(w http.ResponseWriter, r *http.Request) {
 	logger.Get().Info("New Tenant Handler hit.")
-	ctx, err := auth.GetAuthorizerContext(r)
-	if err != nil {
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
 		logger.Logger.Debug("Failed to get authorizer context from request")
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
 		return
@@ -73,6 +83,48 @@ func (h *TenantHandlers) NewTenantHandler(w http.ResponseWriter, r *http.Request
 	params := mux.Vars(r)
 	name := params["name"]
 
+	var dto CreateTenantDTO
+	logger.Get().Debug("Decode optional new tenant data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil && !errors.Is(err, io.EOF) {
+		logger.Get().Error("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	logger.Get().Debug("Resolve league template.", zap.String("LeagueTemplate", dto.LeagueTemplate))
+	template, err := league.Resolve(dto.LeagueTemplate, dto.CustomLeague)
+	if errors.Is(err, league.ErrUnknownTemplate) {
+		logger.Get().Warn("League template is unknown.", zap.String("LeagueTemplate", dto.LeagueTemplate))
+		myhttp.WriteError(w, http.StatusBadRequest, "League template is unknown.")
+		return
+	}
+	if err != nil {
+		logger.Get().Warn("Custom league template is invalid.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "Custom league is invalid.")
+		return
+	}
+
+	if !WithinLimit(h.QuotaPolicy.Tenant.MaxTeams, 0, int64(len(template.Teams))) {
+		logger.Get().Warn("League template exceeds team quota.", zap.Int("Teams", len(template.Teams)))
+		myhttp.WriteError(w, http.StatusForbidden, "Team quota exceeded.")
+		return
+	}
+
+	logger.Get().Debug("Count tenants owned by user.")
+	owned, err := h.TenantRepository.CountOwnedTenantsByUser(ctx.UserId)
+	if err != nil {
+		logger.Get().Error("Failed to count tenants owned by user.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if !WithinLimit(h.QuotaPolicy.MaxTenantsPerUser, owned, 1) {
+		logger.Get().Warn("User reached tenant limit.", zap.Int64("Owned", owned))
+		myhttp.WriteError(w, http.StatusForbidden, "Tenant limit reached.")
+		return
+	}
+
 	logger.Get().Debug("Generate id for tenant.")
 	id := uuid.New().String()
 
@@ -101,13 +153,201 @@ func (h *TenantHandlers) NewTenantHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	h.recordAudit(r, t.Id, audit.TenantCreate, t.Name, "")
+
 	logger.Get().Debug("Publish new tenant message.")
-	h.RabbitMqRouter.PublishMessage(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), "New Tenant", []rabbitmq.RabbitMqBody{
+	h.RabbitMqRouter.PublishMessage(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), NewTenantMessageKey, []rabbitmq.RabbitMqBody{
+		{
+			DataVersion: NewTenantMessageVersion,
+			Data: NewTenantMessage{
+				Tenant: t,
+				League: template,
+			},
+		},
+	})
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(t)
+	if err != nil {
+		logger.Get().Error("Failed to encode tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+func (h *TenantHandlers) GetTenantHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get Tenant Handler hit.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	t, err := h.TenantRepository.SelectTenant(tenantId)
+	if err != nil {
+		logger.Get().Warn("Failed to select tenant.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Tenant not found.")
+		return
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(t)
+	if err != nil {
+		logger.Get().Error("Failed to encode tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+func (h *TenantHandlers) UpdateTenantHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Update Tenant Handler hit.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto UpdateTenantDTO
+	logger.Get().Debug("Decode tenant data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		logger.Get().Warn("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	name := strings.TrimSpace(dto.Name)
+	if name == "" || len(name) > MaxTenantNameLength {
+		logger.Get().Warn("Invalid tenant name.")
+		myhttp.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Name must be between 1 and %d characters.", MaxTenantNameLength))
+		return
+	}
+
+	t := Tenant{
+		Id:   tenantId,
+		Name: name,
+	}
+
+	err = h.TenantRepository.UpdateTenant(t)
+	if err != nil {
+		logger.Get().Warn("Failed to update tenant.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Tenant not found.")
+		return
+	}
+
+	h.recordAudit(r, tenantId, audit.TenantUpdate, tenantId, name)
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(t)
+	if err != nil {
+		logger.Get().Error("Failed to encode tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// DeleteTenantHandler soft deletes the tenant and publishes a "Tenant Deleted" message.
+// The tenant's data is kept until the retention window passes and the purge job removes it.
+func (h *TenantHandlers) DeleteTenantHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Delete Tenant Handler hit.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	t, err := h.TenantRepository.SoftDeleteTenant(tenantId)
+	if err != nil {
+		logger.Get().Warn("Failed to delete tenant.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Tenant not found.")
+		return
+	}
+
+	h.recordAudit(r, tenantId, audit.TenantDelete, tenantId, t.Name)
+
+	logger.Get().Debug("Publish tenant deleted message.")
+	err = h.RabbitMqRouter.PublishMessage(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), TenantDeletedMessageKey, []rabbitmq.RabbitMqBody{
+		{
+			DataVersion: "1.0.0",
+			Data:        t,
+		},
+	})
+	if err != nil {
+		logger.Get().Error("Failed to publish tenant deleted message.", zap.Error(err))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreTenantHandler restores a soft deleted tenant that is still within its retention window.
+// The tenant is no longer in the caller's authorizer context once deleted, so access is
+// checked against the stored membership instead of the tenant scope.
+func (h *TenantHandlers) RestoreTenantHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Restore Tenant Handler hit.")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Get().Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	tenantId := mux.Vars(r)["tenantId"]
+
+	access, err := h.TenantRepository.SelectUserAccess(tenantId, ctx.UserId)
+	if err != nil || !access.AccessLevel.HasPermission(auth.TenantDelete) {
+		logger.Get().Warn("User may not restore tenant.", zap.String("TenantId", tenantId))
+		myhttp.WriteError(w, http.StatusNotFound, "Tenant not found.")
+		return
+	}
+
+	t, err := h.TenantRepository.SelectDeletedTenant(tenantId)
+	if err != nil {
+		logger.Get().Warn("Failed to select deleted tenant.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Tenant not found.")
+		return
+	}
+
+	if access.AccessLevel == auth.Owner {
+		owned, err := h.TenantRepository.CountOwnedTenantsByUser(ctx.UserId)
+		if err != nil {
+			logger.Get().Error("Failed to count owned tenants.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+
+		if !WithinLimit(h.QuotaPolicy.MaxTenantsPerUser, owned, 1) {
+			logger.Get().Warn("User reached tenant limit.")
+			myhttp.WriteError(w, http.StatusForbidden, "Tenant limit reached.")
+			return
+		}
+	}
+
+	err = h.TenantRepository.RestoreTenant(tenantId, time.Now().Add(-h.RetentionPolicy.Retention))
+	if err != nil {
+		logger.Get().Warn("Failed to restore tenant.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Tenant not found.")
+		return
+	}
+
+	t.DeletedAt = nil
+	h.recordAudit(r, tenantId, audit.TenantRestore, tenantId, t.Name)
+
+	logger.Get().Debug("Publish tenant restored message.")
+	err = h.RabbitMqRouter.PublishMessage(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), TenantRestoredMessageKey, []rabbitmq.RabbitMqBody{
 		{
 			DataVersion: "1.0.0",
 			Data:        t,
 		},
 	})
+	if err != nil {
+		logger.Get().Error("Failed to publish tenant restored message.", zap.Error(err))
+	}
 
 	logger.Get().Debug("Encode response JSON and write to response.")
 	w.Header().Set("Content-Type", "application/json")
@@ -118,3 +358,1258 @@ func (h *TenantHandlers) NewTenantHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 }
+
+// RunPurgeJob hard deletes tenants whose retention window has passed, once per purge interval,
+// and publishes a "Tenant Purged" message for each. It blocks, so run it in its own goroutine.
+func (h *TenantHandlers) RunPurgeJob() {
+	logger.Get().Info("Starting tenant purge job.", zap.Duration("Interval", h.RetentionPolicy.PurgeInterval))
+	ticker := time.NewTicker(h.RetentionPolicy.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		h.PurgeDeletedTenants()
+		<-ticker.C
+	}
+}
+
+// PurgeDeletedTenants runs a single pass of the purge job.
+func (h *TenantHandlers) PurgeDeletedTenants() {
+	logger.Get().Debug("Purge deleted tenants.")
+	tenants, err := h.TenantRepository.PurgeDeletedTenants(time.Now().Add(-h.RetentionPolicy.Retention))
+	if err != nil {
+		logger.Get().Error("Failed to purge deleted tenants.", zap.Error(err))
+		return
+	}
+
+	for _, t := range tenants {
+		logger.Get().Info("Purged tenant.", zap.String("TenantId", t.Id))
+		err = h.RabbitMqRouter.PublishMessage(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), TenantPurgedMessageKey, []rabbitmq.RabbitMqBody{
+			{
+				DataVersion: "1.0.0",
+				Data:        t,
+			},
+		})
+		if err != nil {
+			logger.Get().Error("Failed to publish tenant purged message.", zap.Error(err))
+		}
+	}
+}
+
+func (h *TenantHandlers) ExportTenantHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Export Tenant Handler hit.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	t, err := h.TenantRepository.SelectTenant(tenantId)
+	if err != nil {
+		logger.Get().Warn("Failed to select tenant.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Tenant not found.")
+		return
+	}
+
+	members, err := h.TenantRepository.SelectMembers(tenantId)
+	if err != nil {
+		logger.Get().Error("Failed to select members.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	teams, err := h.TenantRepository.SelectArchiveTeams(tenantId)
+	if err != nil {
+		logger.Get().Error("Failed to select teams.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	archive := TenantArchiveDTO{
+		SchemaVersion: ArchiveSchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		Tenant:        *t,
+		Members:       []ArchiveMember{},
+		Teams:         teams,
+	}
+
+	for _, member := range members {
+		archive.Members = append(archive.Members, ArchiveMember{
+			UserAccountId: member.UserAccountId,
+			AccessLevel:   member.AccessLevel,
+		})
+	}
+
+	h.recordAudit(r, tenantId, audit.TenantExport, tenantId, ArchiveSchemaVersion)
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"tenant-%s.json\"", tenantId))
+	err = json.NewEncoder(w).Encode(archive)
+	if err != nil {
+		logger.Get().Error("Failed to encode tenant archive.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// ImportTenantHandler recreates an exported tenant under a new tenant owned by the caller.
+// Every record gets a fresh id. Archived owners other than the caller become admins and
+// members whose user account no longer exists are skipped.
+func (h *TenantHandlers) ImportTenantHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Import Tenant Handler hit.")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var archive TenantArchiveDTO
+	logger.Get().Debug("Decode tenant archive.")
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxArchiveBytes)).Decode(&archive)
+	if err != nil {
+		logger.Get().Warn("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	err = archive.Validate()
+	if errors.Is(err, ErrArchiveVersion) {
+		logger.Get().Warn("Archive schema version is not supported.", zap.String("SchemaVersion", archive.SchemaVersion))
+		myhttp.WriteError(w, http.StatusBadRequest, "Archive schema version is not supported.")
+		return
+	}
+	if err != nil {
+		logger.Get().Warn("Archive is invalid.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "Archive is invalid.")
+		return
+	}
+
+	if !WithinLimit(h.QuotaPolicy.Tenant.MaxTeams, 0, int64(len(archive.Teams))) {
+		logger.Get().Warn("Archive exceeds team quota.", zap.Int("Teams", len(archive.Teams)))
+		myhttp.WriteError(w, http.StatusForbidden, "Team quota exceeded.")
+		return
+	}
+
+	if bytes := storageBytes(archive.Teams); !WithinLimit(h.QuotaPolicy.Tenant.MaxStorageBytes, 0, bytes) {
+		logger.Get().Warn("Archive exceeds storage quota.", zap.Int64("Bytes", bytes))
+		myhttp.WriteError(w, http.StatusForbidden, "Storage quota exceeded.")
+		return
+	}
+
+	owned, err := h.TenantRepository.CountOwnedTenantsByUser(ctx.UserId)
+	if err != nil {
+		logger.Get().Error("Failed to count tenants owned by user.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if !WithinLimit(h.QuotaPolicy.MaxTenantsPerUser, owned, 1) {
+		logger.Get().Warn("User reached tenant limit.", zap.Int64("Owned", owned))
+		myhttp.WriteError(w, http.StatusForbidden, "Tenant limit reached.")
+		return
+	}
+
+	t := Tenant{
+		Id:   uuid.New().String(),
+		Name: strings.TrimSpace(archive.Tenant.Name),
+	}
+
+	owner := TenantUserAccess{TenantId: t.Id, UserAccountId: ctx.UserId, AccessLevel: auth.Owner}
+
+	// Only the caller becomes a member. The other archived members are invited instead, so they
+	// decide whether to join, and archived owners are invited as admins.
+	now := time.Now().UTC()
+	invited := map[string]bool{ctx.UserId: true}
+	invitations := []TenantInvitation{}
+	for _, member := range archive.Members {
+		if invited[member.UserAccountId] {
+			continue
+		}
+
+		accessLevel := member.AccessLevel
+		if accessLevel == auth.Owner {
+			accessLevel = auth.Admin
+		}
+
+		invited[member.UserAccountId] = true
+		invitations = append(invitations, TenantInvitation{
+			Id:            uuid.New().String(),
+			TenantId:      t.Id,
+			UserAccountId: member.UserAccountId,
+			AccessLevel:   accessLevel,
+			InvitedBy:     ctx.UserId,
+			Status:        InvitationPending,
+			CreatedAt:     now,
+			ExpiresAt:     now.Add(InvitationDuration),
+		})
+	}
+
+	if !WithinLimit(h.QuotaPolicy.Tenant.MaxMembers, 0, int64(1+len(invitations))) {
+		logger.Get().Warn("Archive exceeds member quota.", zap.Int("Invitations", len(invitations)))
+		myhttp.WriteError(w, http.StatusForbidden, "Member quota exceeded.")
+		return
+	}
+
+	teams := []ArchiveTeam{}
+	for _, team := range archive.Teams {
+		team.Id = uuid.New().String()
+		teams = append(teams, team)
+	}
+
+	err = h.TenantRepository.InsertArchive(t, owner, invitations, teams)
+	if err != nil {
+		logger.Get().Error("Failed to insert tenant archive.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, t.Id, audit.TenantImport, t.Name, archive.SchemaVersion)
+
+	logger.Get().Debug("Publish new tenant message.")
+	h.RabbitMqRouter.PublishMessage(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), NewTenantMessageKey, []rabbitmq.RabbitMqBody{
+		{
+			DataVersion: NewTenantMessageVersion,
+			Data: NewTenantMessage{
+				Tenant: t,
+				League: league.Template{Name: league.Empty, Teams: []league.TeamDefinition{}},
+			},
+		},
+	})
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(TenantImportDTO{
+		Tenant:      t,
+		Teams:       len(teams),
+		Invitations: len(invitations),
+	})
+	if err != nil {
+		logger.Get().Error("Failed to encode tenant import.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// NewCloneHandler requests an asynchronous copy of the tenant's league data into a new tenant
+// owned by the caller. Members are not copied. The clone is processed from the tenant exchange
+// and its status is polled with GetCloneHandler.
+func (h *TenantHandlers) NewCloneHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("New Clone Handler hit.")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto CreateTenantCloneDTO
+	logger.Get().Debug("Decode optional clone data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil && !errors.Is(err, io.EOF) {
+		logger.Get().Warn("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	source, err := h.TenantRepository.SelectTenant(tenantId)
+	if err != nil {
+		logger.Get().Warn("Failed to select tenant.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Tenant not found.")
+		return
+	}
+
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		name = truncate(source.Name, MaxTenantNameLength-len(" (copy)")) + " (copy)"
+	}
+
+	if len(name) > MaxTenantNameLength {
+		logger.Get().Warn("Clone name is too long.")
+		myhttp.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Name must be between 1 and %d characters.", MaxTenantNameLength))
+		return
+	}
+
+	owned, err := h.TenantRepository.CountOwnedTenantsByUser(ctx.UserId)
+	if err != nil {
+		logger.Get().Error("Failed to count tenants owned by user.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if !WithinLimit(h.QuotaPolicy.MaxTenantsPerUser, owned, 1) {
+		logger.Get().Warn("User reached tenant limit.", zap.Int64("Owned", owned))
+		myhttp.WriteError(w, http.StatusForbidden, "Tenant limit reached.")
+		return
+	}
+
+	// The clone gets the default quota, so the source must fit in it. The copy checks again
+	// against the teams it actually reads.
+	usage, err := h.TenantRepository.SelectUsage(tenantId)
+	if err != nil {
+		logger.Get().Error("Failed to select tenant usage.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if !WithinLimit(h.QuotaPolicy.Tenant.MaxTeams, 0, usage.Teams) {
+		logger.Get().Warn("Source tenant exceeds team quota.", zap.Int64("Teams", usage.Teams))
+		myhttp.WriteError(w, http.StatusForbidden, "Team quota exceeded.")
+		return
+	}
+
+	if !WithinLimit(h.QuotaPolicy.Tenant.MaxStorageBytes, 0, usage.StorageBytes) {
+		logger.Get().Warn("Source tenant exceeds storage quota.", zap.Int64("Bytes", usage.StorageBytes))
+		myhttp.WriteError(w, http.StatusForbidden, "Storage quota exceeded.")
+		return
+	}
+
+	clone := TenantClone{
+		Id:             uuid.New().String(),
+		SourceTenantId: tenantId,
+		TargetTenantId: uuid.New().String(),
+		TargetName:     name,
+		RequestedBy:    ctx.UserId,
+		Status:         ClonePending,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	err = h.TenantRepository.InsertClone(clone)
+	if err != nil {
+		logger.Get().Error("Failed to insert tenant clone.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, tenantId, audit.TenantClone, clone.TargetTenantId, clone.Id)
+
+	logger.Get().Debug("Publish tenant clone requested message.")
+	err = h.RabbitMqRouter.PublishMessage(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), TenantCloneRequestedMessageKey, []rabbitmq.RabbitMqBody{
+		{
+			DataVersion: "1.0.0",
+			Data:        TenantCloneRequestedMessage{CloneId: clone.Id},
+		},
+	})
+	if err != nil {
+		logger.Get().Error("Failed to publish tenant clone requested message.", zap.Error(err))
+		h.failClone(clone.Id, "clone could not be queued")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/tenant/clones/%s", clone.Id))
+	w.WriteHeader(http.StatusAccepted)
+	err = json.NewEncoder(w).Encode(clone)
+	if err != nil {
+		logger.Get().Error("Failed to encode tenant clone.")
+		return
+	}
+}
+
+// GetCloneHandler returns the status of a clone. Only the user who requested it may see it.
+func (h *TenantHandlers) GetCloneHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get Clone Handler hit.")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	clone, err := h.TenantRepository.SelectClone(mux.Vars(r)["cloneId"])
+	if err != nil || clone.RequestedBy != ctx.UserId {
+		logger.Get().Warn("Clone not found for caller.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Clone not found.")
+		return
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(clone)
+	if err != nil {
+		logger.Get().Error("Failed to encode tenant clone.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// ProcessCloneRequestedMessageHandler copies the source tenant's teams into the reserved target
+// tenant, owned by the requester, in one transaction and records the outcome on the clone.
+func (h *TenantHandlers) ProcessCloneRequestedMessageHandler(body rabbitmq.RabbitMqBody) {
+	logger.Get().Info("Process Clone Requested Message handler", zap.Any("Body", body))
+
+	if body.DataVersion != "1.0.0" {
+		logger.Get().Error("Unsupported data version of clone requested message.", zap.String("DataVersion", body.DataVersion))
+		return
+	}
+
+	logger.Get().Debug("Decode clone requested message data.")
+	data, err := json.Marshal(body.Data)
+	if err != nil {
+		logger.Get().Error("Failed to marshal message data.", zap.Error(err))
+		return
+	}
+
+	var message TenantCloneRequestedMessage
+	err = json.Unmarshal(data, &message)
+	if err != nil {
+		logger.Get().Error("Failed to unmarshal message data.", zap.Error(err))
+		return
+	}
+
+	if message.CloneId == "" {
+		logger.Get().Error("Clone requested message has no clone id.")
+		return
+	}
+
+	cloneId := message.CloneId
+
+	err = h.TenantRepository.StartClone(cloneId)
+	if err != nil {
+		logger.Get().Warn("Failed to start tenant clone.", zap.String("CloneId", cloneId), zap.Error(err))
+		return
+	}
+
+	clone, err := h.TenantRepository.SelectClone(cloneId)
+	if err != nil {
+		logger.Get().Error("Failed to select tenant clone.", zap.Error(err))
+		h.failClone(cloneId, "clone could not be read")
+		return
+	}
+
+	teams, err := h.TenantRepository.SelectArchiveTeams(clone.SourceTenantId)
+	if err != nil {
+		logger.Get().Error("Failed to select source teams.", zap.Error(err))
+		h.failClone(clone.Id, "source tenant could not be read")
+		return
+	}
+
+	quota := h.QuotaPolicy.Tenant
+	if !WithinLimit(quota.MaxTeams, 0, int64(len(teams))) {
+		logger.Get().Warn("Source tenant exceeds team quota.", zap.Int("Teams", len(teams)))
+		h.failClone(clone.Id, "team quota exceeded")
+		return
+	}
+
+	if bytes := storageBytes(teams); !WithinLimit(quota.MaxStorageBytes, 0, bytes) {
+		logger.Get().Warn("Source tenant exceeds storage quota.", zap.Int64("Bytes", bytes))
+		h.failClone(clone.Id, "storage quota exceeded")
+		return
+	}
+
+	for i := range teams {
+		teams[i].Id = uuid.New().String()
+	}
+
+	t := Tenant{
+		Id:   clone.TargetTenantId,
+		Name: clone.TargetName,
+	}
+
+	owner := TenantUserAccess{TenantId: t.Id, UserAccountId: clone.RequestedBy, AccessLevel: auth.Owner}
+	err = h.TenantRepository.InsertArchive(t, owner, []TenantInvitation{}, teams)
+	if err != nil {
+		logger.Get().Error("Failed to insert cloned tenant.", zap.Error(err))
+		h.failClone(clone.Id, "cloned tenant could not be stored")
+		return
+	}
+
+	err = h.TenantRepository.FinishClone(clone.Id, CloneCompleted, nil)
+	if err != nil {
+		logger.Get().Error("Failed to complete tenant clone.", zap.Error(err))
+		return
+	}
+
+	logger.Get().Debug("Publish new tenant message.")
+	h.RabbitMqRouter.PublishMessage(os.Getenv("RABBITMQ_EXCHANGE_TENANT"), NewTenantMessageKey, []rabbitmq.RabbitMqBody{
+		{
+			DataVersion: NewTenantMessageVersion,
+			Data: NewTenantMessage{
+				Tenant: t,
+				League: league.Template{Name: league.Empty, Teams: []league.TeamDefinition{}},
+			},
+		},
+	})
+
+	logger.Get().Debug("Successfully cloned tenant.", zap.String("CloneId", clone.Id))
+}
+
+func (h *TenantHandlers) NewInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("New Invitation Handler hit.")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto CreateInvitationDTO
+	logger.Get().Debug("Decode invitation data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		logger.Get().Warn("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	if !dto.AccessLevel.IsValid() || dto.AccessLevel == auth.Owner {
+		logger.Get().Warn("Invalid access level.", zap.String("AccessLevel", string(dto.AccessLevel)))
+		myhttp.WriteError(w, http.StatusBadRequest, "Access level is invalid.")
+		return
+	}
+
+	logger.Get().Debug("Find invited user.", zap.String("Username", dto.Username))
+	userId, err := h.TenantRepository.SelectUserAccountIdByUsername(dto.Username)
+	if err != nil {
+		logger.Get().Warn("Invited user not found.")
+		myhttp.WriteError(w, http.StatusNotFound, "User not found.")
+		return
+	}
+
+	_, err = h.TenantRepository.SelectUserAccess(tenantId, userId)
+	if err == nil {
+		logger.Get().Warn("Invited user is already a member.")
+		myhttp.WriteError(w, http.StatusBadRequest, "User is already a member of tenant.")
+		return
+	}
+
+	if !h.memberQuotaAvailable(w, tenantId) {
+		return
+	}
+
+	now := time.Now().UTC()
+	invitation := TenantInvitation{
+		Id:            uuid.New().String(),
+		TenantId:      tenantId,
+		UserAccountId: userId,
+		AccessLevel:   dto.AccessLevel,
+		InvitedBy:     ctx.UserId,
+		Status:        InvitationPending,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(InvitationDuration),
+	}
+
+	err = h.TenantRepository.InsertInvitation(invitation)
+	if err != nil {
+		logger.Get().Error("Failed to insert invitation.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, tenantId, audit.TenantInvitationCreate, userId, string(invitation.AccessLevel))
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(invitation)
+	if err != nil {
+		logger.Get().Error("Failed to encode invitation.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+func (h *TenantHandlers) GetMyInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get My Invitations Handler hit.")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	invitations, err := h.TenantRepository.SelectPendingInvitationsByUser(ctx.UserId)
+	if err != nil {
+		logger.Logger.Error("Failed to select invitations by user.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var jsonResponse []byte = []byte(`{"count":0,"data":[]}`)
+	if len(invitations) != 0 {
+		jsonResponse, err = json.Marshal(&TenantInvitationGetAllDTO{
+			Count: len(invitations),
+			Data:  invitations,
+		})
+		if err != nil {
+			logger.Logger.Error("Failed to marshal response.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (h *TenantHandlers) AcceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Accept Invitation Handler hit.")
+	invitation, ok := h.pendingInvitationForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.memberQuotaAvailable(w, invitation.TenantId) {
+		return
+	}
+
+	err := h.TenantRepository.AcceptInvitation(*invitation)
+	if err != nil {
+		logger.Get().Error("Failed to accept invitation.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, invitation.TenantId, audit.TenantInvitationAccept, invitation.Id, string(invitation.AccessLevel))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TenantHandlers) DeclineInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Decline Invitation Handler hit.")
+	invitation, ok := h.pendingInvitationForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.TenantRepository.UpdateInvitationStatus(invitation.TenantId, invitation.Id, InvitationDeclined)
+	if err != nil {
+		logger.Get().Error("Failed to decline invitation.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, invitation.TenantId, audit.TenantInvitationDecline, invitation.Id, "")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// NewOwnershipTransferHandler offers ownership of the tenant to another member. Only one
+// transfer may be pending per tenant, so any earlier pending transfer is cancelled.
+func (h *TenantHandlers) NewOwnershipTransferHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("New Ownership Transfer Handler hit.")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto CreateOwnershipTransferDTO
+	logger.Get().Debug("Decode ownership transfer data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.UserAccountId == "" {
+		logger.Get().Warn("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	logger.Get().Debug("Find recipient membership.")
+	member, err := h.TenantRepository.SelectUserAccess(tenantId, dto.UserAccountId)
+	if err != nil {
+		logger.Get().Warn("Recipient is not a member.")
+		myhttp.WriteError(w, http.StatusNotFound, "Member not found.")
+		return
+	}
+
+	if member.AccessLevel == auth.Owner {
+		logger.Get().Warn("Recipient is already an owner.")
+		myhttp.WriteError(w, http.StatusBadRequest, "Member is already an owner.")
+		return
+	}
+
+	now := time.Now().UTC()
+	transfer := OwnershipTransfer{
+		Id:                uuid.New().String(),
+		TenantId:          tenantId,
+		FromUserAccountId: ctx.UserId,
+		ToUserAccountId:   member.UserAccountId,
+		Status:            TransferPending,
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(OwnershipTransferDuration),
+	}
+
+	err = h.TenantRepository.InsertOwnershipTransfer(transfer)
+	if err != nil {
+		logger.Get().Error("Failed to insert ownership transfer.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, tenantId, audit.TenantOwnershipTransferCreate, member.UserAccountId, transfer.Id)
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(transfer)
+	if err != nil {
+		logger.Get().Error("Failed to encode ownership transfer.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+func (h *TenantHandlers) GetMyOwnershipTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get My Ownership Transfers Handler hit.")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	transfers, err := h.TenantRepository.SelectPendingOwnershipTransfersByUser(ctx.UserId)
+	if err != nil {
+		logger.Logger.Error("Failed to select ownership transfers by user.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var jsonResponse []byte = []byte(`{"count":0,"data":[]}`)
+	if len(transfers) != 0 {
+		jsonResponse, err = json.Marshal(&OwnershipTransferGetAllDTO{
+			Count: len(transfers),
+			Data:  transfers,
+		})
+		if err != nil {
+			logger.Logger.Error("Failed to marshal response.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (h *TenantHandlers) AcceptOwnershipTransferHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Accept Ownership Transfer Handler hit.")
+	transfer, ok := h.pendingOwnershipTransferForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.TenantRepository.AcceptOwnershipTransfer(*transfer)
+	if err == ErrOwnershipTransferInvalid {
+		logger.Get().Warn("Ownership transfer can no longer be accepted.")
+		myhttp.WriteError(w, http.StatusBadRequest, "Ownership transfer is no longer valid.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to accept ownership transfer.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, transfer.TenantId, audit.TenantOwnershipTransferAccept, transfer.FromUserAccountId, transfer.Id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TenantHandlers) DeclineOwnershipTransferHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Decline Ownership Transfer Handler hit.")
+	transfer, ok := h.pendingOwnershipTransferForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.TenantRepository.UpdateOwnershipTransferStatus(transfer.Id, TransferDeclined)
+	if err != nil {
+		logger.Get().Error("Failed to decline ownership transfer.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, transfer.TenantId, audit.TenantOwnershipTransferDecline, transfer.FromUserAccountId, transfer.Id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TenantHandlers) GetMembersHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get Members Handler hit.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	members, err := h.TenantRepository.SelectMembers(tenantId)
+	if err != nil {
+		logger.Logger.Error("Failed to select members.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var jsonResponse []byte = []byte(`{"count":0,"data":[]}`)
+	if len(members) != 0 {
+		jsonResponse, err = json.Marshal(&TenantMemberGetAllDTO{
+			Count: len(members),
+			Data:  members,
+		})
+		if err != nil {
+			logger.Logger.Error("Failed to marshal response.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (h *TenantHandlers) UpdateMemberHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Update Member Handler hit.")
+	member, ok := h.manageableMemberFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var dto UpdateMemberDTO
+	logger.Get().Debug("Decode member data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		logger.Get().Warn("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	if !dto.AccessLevel.IsValid() || dto.AccessLevel == auth.Owner {
+		logger.Get().Warn("Invalid access level.", zap.String("AccessLevel", string(dto.AccessLevel)))
+		myhttp.WriteError(w, http.StatusBadRequest, "Access level is invalid.")
+		return
+	}
+
+	previous := member.AccessLevel
+	member.AccessLevel = dto.AccessLevel
+	err = h.TenantRepository.UpdateUserAccess(*member)
+	if err != nil {
+		logger.Get().Error("Failed to update user access.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, member.TenantId, audit.TenantMemberUpdate, member.UserAccountId, fmt.Sprintf("%s -> %s", previous, member.AccessLevel))
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(member)
+	if err != nil {
+		logger.Get().Error("Failed to encode user access.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+func (h *TenantHandlers) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Remove Member Handler hit.")
+	member, ok := h.manageableMemberFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.TenantRepository.DeleteUserAccess(member.TenantId, member.UserAccountId)
+	if err != nil {
+		logger.Get().Error("Failed to delete user access.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, member.TenantId, audit.TenantMemberRemove, member.UserAccountId, string(member.AccessLevel))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TenantHandlers) NewApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("New Api Key Handler hit.")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto CreateApiKeyDTO
+	logger.Get().Debug("Decode api key data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.Name == "" || len(dto.Scopes) == 0 {
+		logger.Get().Warn("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	// A key can never do more than the user who created it.
+	for _, scope := range dto.Scopes {
+		if !scope.IsValid() || !ctx.HasPermission(tenantId, scope) {
+			logger.Get().Warn("Invalid api key scope.", zap.String("Scope", string(scope)))
+			myhttp.WriteError(w, http.StatusBadRequest, "Scope is invalid.")
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	if dto.ExpiresAt != nil && !dto.ExpiresAt.After(now) {
+		logger.Get().Warn("Api key expiry is in the past.")
+		myhttp.WriteError(w, http.StatusBadRequest, "Expiry must be in the future.")
+		return
+	}
+
+	logger.Get().Debug("Generate api key.")
+	secret, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		logger.Get().Error("Cannot generate api key.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	key := fmt.Sprintf("%s%s", ApiKeyPrefix, secret)
+	apiKey := ApiKey{
+		Id:        uuid.New().String(),
+		TenantId:  tenantId,
+		Name:      dto.Name,
+		KeyPrefix: key[:len(ApiKeyPrefix)+6],
+		KeyHash:   auth.HashToken(key),
+		Scopes:    dto.Scopes,
+		CreatedBy: ctx.UserId,
+		CreatedAt: now,
+		ExpiresAt: dto.ExpiresAt,
+	}
+
+	err = h.TenantRepository.InsertApiKey(apiKey)
+	if err != nil {
+		logger.Get().Error("Failed to insert api key.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.recordAudit(r, tenantId, audit.TenantApiKeyCreate, apiKey.Id, apiKey.Name)
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(&ApiKeyCreatedDTO{
+		ApiKey: apiKey,
+		Key:    key,
+	})
+	if err != nil {
+		logger.Get().Error("Failed to encode api key.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+func (h *TenantHandlers) GetAllApiKeysHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get All Api Keys Handler hit.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	apiKeys, err := h.TenantRepository.SelectApiKeysByTenant(tenantId)
+	if err != nil {
+		logger.Logger.Error("Failed to select api keys.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var jsonResponse []byte = []byte(`{"count":0,"data":[]}`)
+	if len(apiKeys) != 0 {
+		jsonResponse, err = json.Marshal(&ApiKeyGetAllDTO{
+			Count: len(apiKeys),
+			Data:  apiKeys,
+		})
+		if err != nil {
+			logger.Logger.Error("Failed to marshal response.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (h *TenantHandlers) RevokeApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Revoke Api Key Handler hit.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	apiKeyId := mux.Vars(r)["apiKeyId"]
+	err := h.TenantRepository.RevokeApiKey(tenantId, apiKeyId)
+	if err != nil {
+		logger.Get().Warn("Failed to revoke api key.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Api key not found.")
+		return
+	}
+
+	h.recordAudit(r, tenantId, audit.TenantApiKeyRevoke, apiKeyId, "")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TenantHandlers) GetUsageHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get Usage Handler hit.")
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	usage, err := h.TenantRepository.SelectUsage(tenantId)
+	if err != nil {
+		logger.Get().Error("Failed to select tenant usage.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	quota := h.QuotaPolicy.QuotaFor(h.TenantRepository, tenantId)
+	dto := TenantUsageDTO{
+		TenantId:      tenantId,
+		Teams:         UsageCounterDTO{Used: usage.Teams, Limit: quota.MaxTeams},
+		Players:       UsageCounterDTO{Used: usage.Players, Limit: quota.MaxPlayers},
+		Members:       UsageCounterDTO{Used: usage.Members, Limit: quota.MaxMembers},
+		ApiCallsToday: UsageCounterDTO{Used: usage.ApiCallsToday, Limit: quota.MaxApiCallsPerDay},
+		StorageBytes:  UsageCounterDTO{Used: usage.StorageBytes, Limit: quota.MaxStorageBytes},
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(dto)
+	if err != nil {
+		logger.Get().Error("Failed to encode tenant usage.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// UpdateQuotaHandler stores the quota of a tenant. Only system administrators may call it,
+// so the tenant is read from the path rather than the request's tenant scope.
+func (h *TenantHandlers) UpdateQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Update Quota Handler hit.")
+	tenantId := mux.Vars(r)["tenantId"]
+
+	var quota TenantQuota
+	logger.Get().Debug("Decode tenant quota data.")
+	err := json.NewDecoder(r.Body).Decode(&quota)
+	if err != nil {
+		logger.Get().Warn("Failed to parse body.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	if !quota.Validate() {
+		logger.Get().Warn("Tenant quota is invalid.")
+		myhttp.WriteError(w, http.StatusBadRequest, "Quota limits must not be negative.")
+		return
+	}
+
+	_, err = h.TenantRepository.SelectTenant(tenantId)
+	if err != nil {
+		logger.Get().Warn("Failed to select tenant.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusNotFound, "Tenant not found.")
+		return
+	}
+
+	err = h.TenantRepository.UpsertQuota(tenantId, quota)
+	if err != nil {
+		logger.Get().Error("Failed to upsert tenant quota.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(quota)
+	if err != nil {
+		logger.Get().Error("Failed to encode tenant quota.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// ApiCallAllowed counts an api call against the tenant and reports whether the tenant is still
+// within its daily quota. The error is returned when the call cannot be counted, so callers
+// can fail closed.
+func (h *TenantHandlers) ApiCallAllowed(tenantId string) (bool, error) {
+	calls, err := h.TenantRepository.IncrementApiCalls(tenantId)
+	if err != nil {
+		return false, err
+	}
+
+	quota := h.QuotaPolicy.QuotaFor(h.TenantRepository, tenantId)
+	return WithinLimit(quota.MaxApiCallsPerDay, calls, 0), nil
+}
+
+// recordAudit records a successful tenant action taken by the caller.
+func (h *TenantHandlers) recordAudit(r *http.Request, tenantId string, action audit.Action, target string, detail string) {
+	audit.Record(h.AuditRepository, r, audit.AuditEvent{
+		TenantId: &tenantId,
+		Action:   action,
+		Outcome:  audit.Success,
+		Target:   audit.String(target),
+		Detail:   audit.String(detail),
+	})
+}
+
+// pendingInvitationForCaller loads the invitation in the path and verifies it is
+// addressed to the caller and can still be answered. On failure the error
+// response has already been written.
+func (h *TenantHandlers) pendingInvitationForCaller(w http.ResponseWriter, r *http.Request) (*TenantInvitation, bool) {
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return nil, false
+	}
+
+	invitation, err := h.TenantRepository.SelectInvitation(mux.Vars(r)["invitationId"])
+	if err != nil || invitation.UserAccountId != ctx.UserId {
+		logger.Get().Warn("Invitation not found for user.")
+		myhttp.WriteError(w, http.StatusNotFound, "Invitation not found.")
+		return nil, false
+	}
+
+	if invitation.Status != InvitationPending || time.Now().UTC().After(invitation.ExpiresAt) {
+		logger.Get().Warn("Invitation is no longer valid.", zap.String("Status", string(invitation.Status)))
+		myhttp.WriteError(w, http.StatusBadRequest, "Invitation is no longer valid.")
+		return nil, false
+	}
+
+	return invitation, true
+}
+
+// pendingOwnershipTransferForCaller loads the ownership transfer in the path and verifies
+// it is addressed to the caller and can still be answered. On failure the error
+// response has already been written.
+func (h *TenantHandlers) pendingOwnershipTransferForCaller(w http.ResponseWriter, r *http.Request) (*OwnershipTransfer, bool) {
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return nil, false
+	}
+
+	transfer, err := h.TenantRepository.SelectOwnershipTransfer(mux.Vars(r)["transferId"])
+	if err != nil || transfer.ToUserAccountId != ctx.UserId {
+		logger.Get().Warn("Ownership transfer not found for user.")
+		myhttp.WriteError(w, http.StatusNotFound, "Ownership transfer not found.")
+		return nil, false
+	}
+
+	if transfer.Status != TransferPending || time.Now().UTC().After(transfer.ExpiresAt) {
+		logger.Get().Warn("Ownership transfer is no longer valid.", zap.String("Status", string(transfer.Status)))
+		myhttp.WriteError(w, http.StatusBadRequest, "Ownership transfer is no longer valid.")
+		return nil, false
+	}
+
+	return transfer, true
+}
+
+// manageableMemberFromPath loads the member in the path for the scoped tenant.
+// Owners cannot be modified through member management, and callers can only
+// change members below their own access level. On failure the error response
+// has already been written.
+func (h *TenantHandlers) manageableMemberFromPath(w http.ResponseWriter, r *http.Request) (*TenantUserAccess, bool) {
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return nil, false
+	}
+
+	tenantId, ok := auth.TenantIdFromContext(r.Context())
+	if !ok {
+		logger.Get().Error("Request was not scoped to a tenant.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return nil, false
+	}
+
+	member, err := h.TenantRepository.SelectUserAccess(tenantId, mux.Vars(r)["userId"])
+	if err != nil {
+		logger.Get().Warn("Member not found.")
+		myhttp.WriteError(w, http.StatusNotFound, "Member not found.")
+		return nil, false
+	}
+
+	if member.AccessLevel == auth.Owner {
+		logger.Get().Warn("Attempted to modify owner access.")
+		myhttp.WriteError(w, http.StatusBadRequest, "Owner access cannot be changed.")
+		return nil, false
+	}
+
+	if !ctx.TenantAccess[tenantId].Outranks(member.AccessLevel) {
+		logger.Get().Warn("Attempted to modify member at or above caller access level.", zap.String("AccessLevel", string(member.AccessLevel)))
+		myhttp.WriteError(w, http.StatusForbidden, "Members at or above your access level cannot be changed.")
+		return nil, false
+	}
+
+	return member, true
+}
+
+// memberQuotaAvailable writes a 403 and returns false when the tenant cannot take another member.
+func (h *TenantHandlers) memberQuotaAvailable(w http.ResponseWriter, tenantId string) bool {
+	usage, err := h.TenantRepository.SelectUsage(tenantId)
+	if err != nil {
+		logger.Get().Error("Failed to select tenant usage.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return false
+	}
+
+	quota := h.QuotaPolicy.QuotaFor(h.TenantRepository, tenantId)
+	if !WithinLimit(quota.MaxMembers, usage.Members, 1) {
+		logger.Get().Warn("Tenant reached member quota.", zap.String("TenantId", tenantId))
+		myhttp.WriteError(w, http.StatusForbidden, "Member quota exceeded.")
+		return false
+	}
+
+	return true
+}
+
+func (h *TenantHandlers) failClone(cloneId string, reason string) {
+	err := h.TenantRepository.FinishClone(cloneId, CloneFailed, &reason)
+	if err != nil {
+		logger.Get().Error("Failed to mark tenant clone failed.", zap.String("CloneId", cloneId), zap.Error(err))
+	}
+}
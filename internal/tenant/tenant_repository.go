@@ -2,8 +2,14 @@ package tenant
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 
+	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/database"
 	"github.com/ccthomas/gridiron/pkg/logger"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
 )
 
 type TenantRepositoryImpl struct {
@@ -30,22 +36,162 @@ func (r *TenantRepositoryImpl) InsertTenant(tenant Tenant) error {
 	return nil
 }
 
+func (r *TenantRepositoryImpl) SelectTenant(id string) (*Tenant, error) {
+	logger.Get().Debug("Select tenant by id.")
+	row := r.DB.QueryRow("SELECT id, name FROM tenant.tenant WHERE id = $1 AND deleted_at IS NULL", id)
+
+	var tenant Tenant
+	err := row.Scan(&tenant.Id, &tenant.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Tenant not found.")
+			return nil, fmt.Errorf("tenant not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found tenant.")
+	return &tenant, nil
+}
+
+func (r *TenantRepositoryImpl) UpdateTenant(tenant Tenant) error {
+	logger.Get().Debug("Update tenant.")
+	result, err := r.DB.Exec("UPDATE tenant.tenant SET name = $2 WHERE id = $1", tenant.Id, tenant.Name)
+	if err != nil {
+		logger.Get().Warn("Failed to update tenant.")
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		logger.Get().Debug("Tenant not found.")
+		return fmt.Errorf("tenant not found")
+	}
+
+	logger.Get().Debug("Successfully updated tenant.")
+	return nil
+}
+
+// SoftDeleteTenant marks the tenant deleted. Its data is kept until PurgeDeletedTenants removes it.
+func (r *TenantRepositoryImpl) SoftDeleteTenant(id string) (*Tenant, error) {
+	logger.Get().Debug("Soft delete tenant.")
+	row := r.DB.QueryRow("UPDATE tenant.tenant SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING id, name, deleted_at", id)
+
+	var tenant Tenant
+	err := row.Scan(&tenant.Id, &tenant.Name, &tenant.DeletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Tenant not found.")
+			return nil, fmt.Errorf("tenant not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Successfully deleted tenant.")
+	return &tenant, nil
+}
+
+func (r *TenantRepositoryImpl) SelectDeletedTenant(id string) (*Tenant, error) {
+	logger.Get().Debug("Select deleted tenant by id.")
+	row := r.DB.QueryRow("SELECT id, name, deleted_at FROM tenant.tenant WHERE id = $1 AND deleted_at IS NOT NULL", id)
+
+	var tenant Tenant
+	err := row.Scan(&tenant.Id, &tenant.Name, &tenant.DeletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Deleted tenant not found.")
+			return nil, fmt.Errorf("tenant not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found deleted tenant.")
+	return &tenant, nil
+}
+
+// RestoreTenant clears the deletion of a tenant deleted after deletedAfter.
+func (r *TenantRepositoryImpl) RestoreTenant(id string, deletedAfter time.Time) error {
+	logger.Get().Debug("Restore tenant.")
+	result, err := r.DB.Exec("UPDATE tenant.tenant SET deleted_at = NULL WHERE id = $1 AND deleted_at > $2", id, deletedAfter)
+	if err != nil {
+		logger.Get().Warn("Failed to restore tenant.")
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		logger.Get().Debug("Deleted tenant not found.")
+		return fmt.Errorf("tenant not found")
+	}
+
+	logger.Get().Debug("Successfully restored tenant.")
+	return nil
+}
+
+// PurgeDeletedTenants hard deletes tenants deleted before deletedBefore. Tenant scoped data is
+// removed by the foreign key cascades. The purged tenants are returned.
+func (r *TenantRepositoryImpl) PurgeDeletedTenants(deletedBefore time.Time) ([]Tenant, error) {
+	logger.Get().Debug("Purge deleted tenants.")
+	rows, err := r.DB.Query("DELETE FROM tenant.tenant WHERE deleted_at < $1 RETURNING id, name, deleted_at", deletedBefore)
+	if err != nil {
+		logger.Get().Warn("Failed to purge deleted tenants.", zap.Error(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tenants := []Tenant{}
+	for rows.Next() {
+		var tenant Tenant
+		if err := rows.Scan(&tenant.Id, &tenant.Name, &tenant.DeletedAt); err != nil {
+			logger.Get().Warn("Failed to scan row.")
+			return nil, err
+		}
+
+		tenants = append(tenants, tenant)
+	}
+
+	return tenants, rows.Err()
+}
+
 func (r *TenantRepositoryImpl) InsertUserAccess(userAccess TenantUserAccess) error {
 	logger.Get().Debug("Insert user access.")
-	_, err := r.DB.Exec("INSERT INTO tenant.tenant_user_access (user_account_id, tenant_id, access_level) VALUES ($1, $2, $3)", userAccess.UserAccountId, userAccess.TenantId, userAccess.AccessLevel)
+	tx, err := database.BeginTenantTx(r.DB, userAccess.TenantId)
+	if err != nil {
+		return err
+	}
 
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO tenant.tenant_user_access (user_account_id, tenant_id, access_level) VALUES ($1, $2, $3)", userAccess.UserAccountId, userAccess.TenantId, userAccess.AccessLevel)
 	if err != nil {
 		logger.Get().Warn("Failed to insert user access.")
 		return err
 	}
 
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
 	logger.Get().Debug("Successfully inserted user access.")
 	return nil
 }
 
+// SelectTenantByUser lists the user's tenants across every tenant, so it runs on the owner
+// connection rather than in a tenant transaction.
 func (r *TenantRepositoryImpl) SelectTenantByUser(userId string) ([]Tenant, error) {
 	logger.Get().Debug("Select tenants by user id.")
-	rows, err := r.DB.Query("SELECT t.id, t.name FROM tenant.tenant t JOIN tenant.tenant_user_access ua ON t.id = ua.tenant_id WHERE ua.user_account_id = $1 ORDER BY t.name ASC", userId)
+	rows, err := r.DB.Query("SELECT t.id, t.name FROM tenant.tenant t JOIN tenant.tenant_user_access ua ON t.id = ua.tenant_id WHERE ua.user_account_id = $1 AND t.deleted_at IS NULL ORDER BY t.name ASC", userId)
 
 	if err != nil {
 		logger.Get().Warn("Failed to select tenant by user.")
@@ -72,9 +218,40 @@ func (r *TenantRepositoryImpl) SelectTenantByUser(userId string) ([]Tenant, erro
 	return tenants, nil
 }
 
+// SelectSoleOwnedTenantsByUser selects the tenants the user owns that have no other owner.
+// It looks across tenants, so it is not scoped to one.
+func (r *TenantRepositoryImpl) SelectSoleOwnedTenantsByUser(userId string) ([]Tenant, error) {
+	logger.Get().Debug("Select sole owned tenants by user id.")
+	rows, err := r.DB.Query(
+		"SELECT t.id, t.name FROM tenant.tenant t JOIN tenant.tenant_user_access ua ON t.id = ua.tenant_id WHERE ua.user_account_id = $1 AND ua.access_level = $2 AND t.deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM tenant.tenant_user_access o WHERE o.tenant_id = t.id AND o.access_level = $2 AND o.user_account_id <> $1) ORDER BY t.name ASC",
+		userId, auth.Owner,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to select sole owned tenants by user.")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var tenants []Tenant
+	for rows.Next() {
+		var tenant Tenant
+		if err := rows.Scan(&tenant.Id, &tenant.Name); err != nil {
+			logger.Get().Warn("Failed to scan row.")
+			return nil, err
+		}
+
+		tenants = append(tenants, tenant)
+	}
+
+	return tenants, nil
+}
+
+// SelectTenantAccessByUser builds the authorizer context before any tenant is chosen, so it
+// reads every tenant's access rows on the owner connection.
 func (r *TenantRepositoryImpl) SelectTenantAccessByUser(userId string) ([]TenantUserAccess, error) {
 	logger.Get().Debug("Select tenant user access by user id.")
-	rows, err := r.DB.Query("SELECT tenant_id, user_account_id, access_level FROM tenant.tenant_user_access WHERE user_account_id = $1", userId)
+	rows, err := r.DB.Query("SELECT ua.tenant_id, ua.user_account_id, ua.access_level FROM tenant.tenant_user_access ua JOIN tenant.tenant t ON t.id = ua.tenant_id WHERE ua.user_account_id = $1 AND t.deleted_at IS NULL", userId)
 
 	if err != nil {
 		logger.Get().Warn("Failed to select tenant user accesses by user.")
@@ -100,3 +277,911 @@ func (r *TenantRepositoryImpl) SelectTenantAccessByUser(userId string) ([]Tenant
 	logger.Get().Debug("Return tenant user accesses.")
 	return tenantAccessArr, nil
 }
+
+func (r *TenantRepositoryImpl) SelectUserAccess(tenantId string, userId string) (*TenantUserAccess, error) {
+	logger.Get().Debug("Select tenant user access.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	row := tx.QueryRow("SELECT tenant_id, user_account_id, access_level FROM tenant.tenant_user_access WHERE tenant_id = $1 AND user_account_id = $2", tenantId, userId)
+
+	var userAccess TenantUserAccess
+	err = row.Scan(&userAccess.TenantId, &userAccess.UserAccountId, &userAccess.AccessLevel)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Tenant user access not found.")
+			return nil, fmt.Errorf("tenant user access not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found tenant user access.")
+	return &userAccess, nil
+}
+
+func (r *TenantRepositoryImpl) UpdateUserAccess(userAccess TenantUserAccess) error {
+	logger.Get().Debug("Update user access.")
+	tx, err := database.BeginTenantTx(r.DB, userAccess.TenantId)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE tenant.tenant_user_access SET access_level = $3 WHERE tenant_id = $1 AND user_account_id = $2", userAccess.TenantId, userAccess.UserAccountId, userAccess.AccessLevel)
+	if err != nil {
+		logger.Get().Warn("Failed to update user access.")
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully updated user access.")
+	return nil
+}
+
+func (r *TenantRepositoryImpl) DeleteUserAccess(tenantId string, userId string) error {
+	logger.Get().Debug("Delete user access.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM tenant.tenant_user_access WHERE tenant_id = $1 AND user_account_id = $2", tenantId, userId)
+	if err != nil {
+		logger.Get().Warn("Failed to delete user access.")
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully deleted user access.")
+	return nil
+}
+
+func (r *TenantRepositoryImpl) SelectMembers(tenantId string) ([]TenantMember, error) {
+	logger.Get().Debug("Select tenant members.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT ua.user_account_id, u.username, ua.access_level FROM tenant.tenant_user_access ua JOIN user_account.user_account u ON u.id = ua.user_account_id WHERE ua.tenant_id = $1 ORDER BY u.username ASC", tenantId)
+
+	if err != nil {
+		logger.Get().Warn("Failed to select tenant members.")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	logger.Get().Debug("Start scanning rows.")
+	var members []TenantMember
+	for rows.Next() {
+		var member TenantMember
+		logger.Get().Debug("Scan next row.")
+		if err := rows.Scan(&member.UserAccountId, &member.Username, &member.AccessLevel); err != nil {
+			logger.Get().Warn("Failed to scan row.")
+			return nil, err
+		}
+
+		logger.Get().Debug("Add member to members array.")
+		members = append(members, member)
+	}
+
+	logger.Get().Debug("Return members.")
+	return members, rows.Err()
+}
+
+// SelectUserAccountIdByUsername resolves the user an invitation is addressed to.
+func (r *TenantRepositoryImpl) SelectUserAccountIdByUsername(username string) (string, error) {
+	logger.Get().Debug("Select user account id by username.")
+	row := r.DB.QueryRow("SELECT id FROM user_account.user_account WHERE username = $1", username)
+
+	var id string
+	err := row.Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("User account not found.")
+			return "", fmt.Errorf("user account not found")
+		}
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (r *TenantRepositoryImpl) InsertInvitation(invitation TenantInvitation) error {
+	logger.Get().Debug("Insert invitation.")
+	tx, err := database.BeginTenantTx(r.DB, invitation.TenantId)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT INTO tenant.tenant_invitation (id, tenant_id, user_account_id, access_level, invited_by, status, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		invitation.Id, invitation.TenantId, invitation.UserAccountId, invitation.AccessLevel, invitation.InvitedBy, invitation.Status, invitation.CreatedAt, invitation.ExpiresAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert invitation.")
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted invitation.")
+	return nil
+}
+
+// SelectInvitation looks an invitation up by id for the invited user, who is not yet a member
+// of its tenant, so it runs on the owner connection. Callers check the invitation is theirs.
+func (r *TenantRepositoryImpl) SelectInvitation(id string) (*TenantInvitation, error) {
+	logger.Get().Debug("Select invitation by id.")
+	row := r.DB.QueryRow("SELECT id, tenant_id, user_account_id, access_level, invited_by, status, created_at, expires_at FROM tenant.tenant_invitation WHERE id = $1", id)
+
+	var invitation TenantInvitation
+	err := row.Scan(&invitation.Id, &invitation.TenantId, &invitation.UserAccountId, &invitation.AccessLevel, &invitation.InvitedBy, &invitation.Status, &invitation.CreatedAt, &invitation.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Invitation not found.")
+			return nil, fmt.Errorf("invitation not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found invitation.")
+	return &invitation, nil
+}
+
+// SelectPendingInvitationsByUser lists the user's invitations from every tenant, so it runs on
+// the owner connection.
+func (r *TenantRepositoryImpl) SelectPendingInvitationsByUser(userId string) ([]TenantInvitation, error) {
+	logger.Get().Debug("Select pending invitations by user id.")
+	rows, err := r.DB.Query("SELECT id, tenant_id, user_account_id, access_level, invited_by, status, created_at, expires_at FROM tenant.tenant_invitation WHERE user_account_id = $1 AND status = $2 AND expires_at > NOW() AND tenant_id IN (SELECT id FROM tenant.tenant WHERE deleted_at IS NULL) ORDER BY created_at ASC", userId, InvitationPending)
+
+	if err != nil {
+		logger.Get().Warn("Failed to select invitations by user.")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	logger.Get().Debug("Start scanning rows.")
+	var invitations []TenantInvitation
+	for rows.Next() {
+		var invitation TenantInvitation
+		logger.Get().Debug("Scan next row.")
+		if err := rows.Scan(&invitation.Id, &invitation.TenantId, &invitation.UserAccountId, &invitation.AccessLevel, &invitation.InvitedBy, &invitation.Status, &invitation.CreatedAt, &invitation.ExpiresAt); err != nil {
+			logger.Get().Warn("Failed to scan row.")
+			return nil, err
+		}
+
+		logger.Get().Debug("Add invitation to invitations array.")
+		invitations = append(invitations, invitation)
+	}
+
+	logger.Get().Debug("Return invitations.")
+	return invitations, nil
+}
+
+// AcceptInvitation marks the invitation accepted and grants its access level in one transaction.
+func (r *TenantRepositoryImpl) AcceptInvitation(invitation TenantInvitation) error {
+	logger.Get().Debug("Accept invitation.")
+
+	tx, err := database.BeginTenantTx(r.DB, invitation.TenantId)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("UPDATE tenant.tenant_invitation SET status = $2 WHERE id = $1 AND status = $3 AND expires_at > NOW() AND tenant_id IN (SELECT id FROM tenant.tenant WHERE deleted_at IS NULL)", invitation.Id, InvitationAccepted, InvitationPending)
+	if err != nil {
+		logger.Get().Warn("Failed to update invitation.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if count == 0 {
+		tx.Rollback()
+		return fmt.Errorf("invitation is no longer pending")
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO tenant.tenant_user_access (tenant_id, user_account_id, access_level) VALUES ($1, $2, $3)",
+		invitation.TenantId, invitation.UserAccountId, invitation.AccessLevel,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert user access.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully accepted invitation.")
+	return nil
+}
+
+func (r *TenantRepositoryImpl) UpdateInvitationStatus(tenantId string, id string, status InvitationStatus) error {
+	logger.Get().Debug("Update invitation status.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE tenant.tenant_invitation SET status = $3 WHERE tenant_id = $1 AND id = $2", tenantId, id, status)
+	if err != nil {
+		logger.Get().Warn("Failed to update invitation status.")
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully updated invitation status.")
+	return nil
+}
+
+// InsertOwnershipTransfer inserts a pending transfer, cancelling any transfer already
+// pending for the tenant, in one transaction.
+func (r *TenantRepositoryImpl) InsertOwnershipTransfer(transfer OwnershipTransfer) error {
+	logger.Get().Debug("Insert ownership transfer.")
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE tenant.ownership_transfer SET status = $2 WHERE tenant_id = $1 AND status = $3", transfer.TenantId, TransferCancelled, TransferPending)
+	if err != nil {
+		logger.Get().Warn("Failed to cancel pending ownership transfers.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO tenant.ownership_transfer (id, tenant_id, from_user_account_id, to_user_account_id, status, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		transfer.Id, transfer.TenantId, transfer.FromUserAccountId, transfer.ToUserAccountId, transfer.Status, transfer.CreatedAt, transfer.ExpiresAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert ownership transfer.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted ownership transfer.")
+	return nil
+}
+
+func (r *TenantRepositoryImpl) SelectOwnershipTransfer(id string) (*OwnershipTransfer, error) {
+	logger.Get().Debug("Select ownership transfer by id.")
+	row := r.DB.QueryRow("SELECT id, tenant_id, from_user_account_id, to_user_account_id, status, created_at, expires_at FROM tenant.ownership_transfer WHERE id = $1", id)
+
+	var transfer OwnershipTransfer
+	err := row.Scan(&transfer.Id, &transfer.TenantId, &transfer.FromUserAccountId, &transfer.ToUserAccountId, &transfer.Status, &transfer.CreatedAt, &transfer.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Ownership transfer not found.")
+			return nil, fmt.Errorf("ownership transfer not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found ownership transfer.")
+	return &transfer, nil
+}
+
+func (r *TenantRepositoryImpl) SelectPendingOwnershipTransfersByUser(userId string) ([]OwnershipTransfer, error) {
+	logger.Get().Debug("Select pending ownership transfers by user id.")
+	rows, err := r.DB.Query("SELECT id, tenant_id, from_user_account_id, to_user_account_id, status, created_at, expires_at FROM tenant.ownership_transfer WHERE to_user_account_id = $1 AND status = $2 AND expires_at > NOW() AND tenant_id IN (SELECT id FROM tenant.tenant WHERE deleted_at IS NULL) ORDER BY created_at ASC", userId, TransferPending)
+
+	if err != nil {
+		logger.Get().Warn("Failed to select ownership transfers by user.")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	logger.Get().Debug("Start scanning rows.")
+	var transfers []OwnershipTransfer
+	for rows.Next() {
+		var transfer OwnershipTransfer
+		logger.Get().Debug("Scan next row.")
+		if err := rows.Scan(&transfer.Id, &transfer.TenantId, &transfer.FromUserAccountId, &transfer.ToUserAccountId, &transfer.Status, &transfer.CreatedAt, &transfer.ExpiresAt); err != nil {
+			logger.Get().Warn("Failed to scan row.")
+			return nil, err
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	logger.Get().Debug("Return ownership transfers.")
+	return transfers, nil
+}
+
+// AcceptOwnershipTransfer makes the recipient an owner and demotes the previous owner to
+// admin in one transaction. The recipient is promoted first so the tenant always has an
+// owner. ErrOwnershipTransferInvalid is returned when the transfer is no longer pending,
+// the recipient is no longer a member or the sender is no longer an owner.
+func (r *TenantRepositoryImpl) AcceptOwnershipTransfer(transfer OwnershipTransfer) error {
+	logger.Get().Debug("Accept ownership transfer.")
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return err
+	}
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{
+			"UPDATE tenant.ownership_transfer SET status = $2 WHERE id = $1 AND status = $3 AND expires_at > NOW() AND tenant_id IN (SELECT id FROM tenant.tenant WHERE deleted_at IS NULL)",
+			[]any{transfer.Id, TransferAccepted, TransferPending},
+		},
+		{
+			"UPDATE tenant.tenant_user_access SET access_level = $3 WHERE tenant_id = $1 AND user_account_id = $2 AND access_level <> $3",
+			[]any{transfer.TenantId, transfer.ToUserAccountId, auth.Owner},
+		},
+		{
+			"UPDATE tenant.tenant_user_access SET access_level = $3 WHERE tenant_id = $1 AND user_account_id = $2 AND access_level = $4",
+			[]any{transfer.TenantId, transfer.FromUserAccountId, auth.Admin, auth.Owner},
+		},
+	}
+
+	for i, statement := range statements {
+		// The transfer itself is not tenant scoped; the access changes after it are.
+		if i == 1 {
+			err = database.ScopeTenantTx(tx, transfer.TenantId)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		result, err := tx.Exec(statement.query, statement.args...)
+		if err != nil {
+			logger.Get().Warn("Failed to accept ownership transfer.", zap.Error(err))
+			tx.Rollback()
+			return err
+		}
+
+		count, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if count != 1 {
+			tx.Rollback()
+			return ErrOwnershipTransferInvalid
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully accepted ownership transfer.")
+	return nil
+}
+
+func (r *TenantRepositoryImpl) UpdateOwnershipTransferStatus(id string, status OwnershipTransferStatus) error {
+	logger.Get().Debug("Update ownership transfer status.")
+	_, err := r.DB.Exec("UPDATE tenant.ownership_transfer SET status = $2 WHERE id = $1", id, status)
+	if err != nil {
+		logger.Get().Warn("Failed to update ownership transfer status.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully updated ownership transfer status.")
+	return nil
+}
+
+func (r *TenantRepositoryImpl) InsertApiKey(apiKey ApiKey) error {
+	logger.Get().Debug("Insert api key.")
+
+	scopes := make([]string, len(apiKey.Scopes))
+	for i, scope := range apiKey.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	tx, err := database.BeginTenantTx(r.DB, apiKey.TenantId)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT INTO tenant.api_key (id, tenant_id, name, key_prefix, key_hash, scopes, created_by, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		apiKey.Id, apiKey.TenantId, apiKey.Name, apiKey.KeyPrefix, apiKey.KeyHash, pq.Array(scopes), apiKey.CreatedBy, apiKey.CreatedAt, apiKey.ExpiresAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert api key.")
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted api key.")
+	return nil
+}
+
+func (r *TenantRepositoryImpl) SelectApiKeysByTenant(tenantId string) ([]ApiKey, error) {
+	logger.Get().Debug("Select api keys by tenant id.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, tenant_id, name, key_prefix, key_hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at FROM tenant.api_key WHERE tenant_id = $1 ORDER BY created_at ASC", tenantId)
+
+	if err != nil {
+		logger.Get().Warn("Failed to select api keys by tenant.")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	logger.Get().Debug("Start scanning rows.")
+	var apiKeys []ApiKey
+	for rows.Next() {
+		logger.Get().Debug("Scan next row.")
+		apiKey, err := scanApiKey(rows)
+		if err != nil {
+			logger.Get().Warn("Failed to scan row.")
+			return nil, err
+		}
+
+		logger.Get().Debug("Add api key to api keys array.")
+		apiKeys = append(apiKeys, *apiKey)
+	}
+
+	logger.Get().Debug("Return api keys.")
+	return apiKeys, rows.Err()
+}
+
+// SelectApiKeyByHash authenticates an api key before its tenant is known, so it runs on the
+// owner connection.
+func (r *TenantRepositoryImpl) SelectApiKeyByHash(keyHash string) (*ApiKey, error) {
+	logger.Get().Debug("Select api key by hash.")
+	row := r.DB.QueryRow("SELECT id, tenant_id, name, key_prefix, key_hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at FROM tenant.api_key WHERE key_hash = $1 AND tenant_id IN (SELECT id FROM tenant.tenant WHERE deleted_at IS NULL)", keyHash)
+
+	apiKey, err := scanApiKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Api key not found.")
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found api key.", zap.String("Id", apiKey.Id))
+	return apiKey, nil
+}
+
+// RevokeApiKey revokes an active api key belonging to the tenant.
+func (r *TenantRepositoryImpl) RevokeApiKey(tenantId string, id string) error {
+	logger.Get().Debug("Revoke api key.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE tenant.api_key SET revoked_at = NOW() WHERE tenant_id = $1 AND id = $2 AND revoked_at IS NULL", tenantId, id)
+	if err != nil {
+		logger.Get().Warn("Failed to revoke api key.")
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		logger.Get().Debug("Api key not found.")
+		return fmt.Errorf("api key not found")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully revoked api key.")
+	return nil
+}
+
+// UpdateApiKeyLastUsed follows SelectApiKeyByHash during authentication and likewise runs on
+// the owner connection.
+func (r *TenantRepositoryImpl) UpdateApiKeyLastUsed(id string) error {
+	logger.Get().Debug("Update api key last used.")
+	_, err := r.DB.Exec("UPDATE tenant.api_key SET last_used_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		logger.Get().Warn("Failed to update api key last used.")
+		return err
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanApiKey(row rowScanner) (*ApiKey, error) {
+	var apiKey ApiKey
+	var scopes []string
+	err := row.Scan(&apiKey.Id, &apiKey.TenantId, &apiKey.Name, &apiKey.KeyPrefix, &apiKey.KeyHash, pq.Array(&scopes), &apiKey.CreatedBy, &apiKey.CreatedAt, &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey.Scopes = make([]auth.Permission, len(scopes))
+	for i, scope := range scopes {
+		apiKey.Scopes[i] = auth.Permission(scope)
+	}
+
+	return &apiKey, nil
+}
+
+// CountOwnedTenantsByUser counts across tenants for the per user tenant limit, so it is not
+// scoped to one.
+func (r *TenantRepositoryImpl) CountOwnedTenantsByUser(userId string) (int64, error) {
+	logger.Get().Debug("Count tenants owned by user.")
+	row := r.DB.QueryRow("SELECT COUNT(*) FROM tenant.tenant_user_access ua JOIN tenant.tenant t ON t.id = ua.tenant_id WHERE ua.user_account_id = $1 AND ua.access_level = 'OWNER' AND t.deleted_at IS NULL", userId)
+
+	var count int64
+	err := row.Scan(&count)
+	if err != nil {
+		logger.Get().Warn("Failed to count tenants owned by user.", zap.Error(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *TenantRepositoryImpl) SelectQuota(tenantId string) (*TenantQuota, error) {
+	logger.Get().Debug("Select tenant quota.")
+	row := r.DB.QueryRow(
+		"SELECT max_teams, max_players, max_members, max_api_calls_per_day, max_storage_bytes FROM tenant.tenant_quota WHERE tenant_id = $1",
+		tenantId,
+	)
+
+	var quota TenantQuota
+	err := row.Scan(&quota.MaxTeams, &quota.MaxPlayers, &quota.MaxMembers, &quota.MaxApiCallsPerDay, &quota.MaxStorageBytes)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Tenant quota not found.")
+			return nil, fmt.Errorf("tenant quota not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found tenant quota.")
+	return &quota, nil
+}
+
+func (r *TenantRepositoryImpl) UpsertQuota(tenantId string, quota TenantQuota) error {
+	logger.Get().Debug("Upsert tenant quota.")
+	_, err := r.DB.Exec(
+		"INSERT INTO tenant.tenant_quota (tenant_id, max_teams, max_players, max_members, max_api_calls_per_day, max_storage_bytes) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (tenant_id) DO UPDATE SET max_teams = EXCLUDED.max_teams, max_players = EXCLUDED.max_players, max_members = EXCLUDED.max_members, max_api_calls_per_day = EXCLUDED.max_api_calls_per_day, max_storage_bytes = EXCLUDED.max_storage_bytes, updated_at = NOW()",
+		tenantId, quota.MaxTeams, quota.MaxPlayers, quota.MaxMembers, quota.MaxApiCallsPerDay, quota.MaxStorageBytes,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to upsert tenant quota.", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// SelectUsage counts what the tenant stores. Storage is the bytes of the text fields of the tenant's teams.
+func (r *TenantRepositoryImpl) SelectUsage(tenantId string) (*TenantUsage, error) {
+	logger.Get().Debug("Select tenant usage.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	row := tx.QueryRow(
+		"SELECT (SELECT COUNT(*) FROM team.team WHERE tenant_id = $1), (SELECT COUNT(*) FROM tenant.tenant_user_access WHERE tenant_id = $1), COALESCE((SELECT calls FROM tenant.api_usage WHERE tenant_id = $1 AND day = (NOW() AT TIME ZONE 'UTC')::date), 0), COALESCE((SELECT SUM(octet_length(t.name) + COALESCE(octet_length(t.abbreviation), 0) + octet_length(t.location) + octet_length(t.nickname) + octet_length(t.primary_color) + octet_length(t.secondary_color) + octet_length(t.venue)) FROM team.team t WHERE t.tenant_id = $1), 0)",
+		tenantId,
+	)
+
+	var usage TenantUsage
+	err = row.Scan(&usage.Teams, &usage.Members, &usage.ApiCallsToday, &usage.StorageBytes)
+	if err != nil {
+		logger.Get().Warn("Failed to select tenant usage.", zap.Error(err))
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+// IncrementApiCalls counts an api call against the tenant for the current day and returns the day's total.
+func (r *TenantRepositoryImpl) IncrementApiCalls(tenantId string) (int64, error) {
+	logger.Get().Debug("Increment tenant api calls.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	row := tx.QueryRow(
+		"INSERT INTO tenant.api_usage (tenant_id, day, calls) VALUES ($1, (NOW() AT TIME ZONE 'UTC')::date, 1) ON CONFLICT (tenant_id, day) DO UPDATE SET calls = tenant.api_usage.calls + 1 RETURNING calls",
+		tenantId,
+	)
+
+	var calls int64
+	err = row.Scan(&calls)
+	if err != nil {
+		logger.Get().Warn("Failed to increment tenant api calls.", zap.Error(err))
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return 0, err
+	}
+
+	return calls, nil
+}
+
+func (r *TenantRepositoryImpl) SelectArchiveTeams(tenantId string) ([]ArchiveTeam, error) {
+	logger.Get().Debug("Select archive teams.")
+	tx, err := database.BeginTenantTx(r.DB, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, name, COALESCE(abbreviation, ''), location, nickname, primary_color, secondary_color, venue, founded_year, active FROM team.team WHERE tenant_id = $1 ORDER BY name ASC", tenantId)
+	if err != nil {
+		logger.Get().Warn("Failed to select archive teams.", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []ArchiveTeam{}
+	for rows.Next() {
+		var team ArchiveTeam
+		err := rows.Scan(&team.Id, &team.Name, &team.Abbreviation, &team.Location, &team.Nickname, &team.PrimaryColor, &team.SecondaryColor, &team.Venue, &team.FoundedYear, &team.Active)
+		if err != nil {
+			logger.Get().Warn("Failed to scan archive team.", zap.Error(err))
+			return nil, err
+		}
+
+		teams = append(teams, team)
+	}
+
+	return teams, rows.Err()
+}
+
+// InsertArchive creates the tenant with its owner, invitations and teams in one transaction.
+// Invitations to user accounts that do not exist are skipped.
+func (r *TenantRepositoryImpl) InsertArchive(tenant Tenant, owner TenantUserAccess, invitations []TenantInvitation, teams []ArchiveTeam) error {
+	logger.Get().Debug("Insert tenant archive.")
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO tenant.tenant (id, name) VALUES ($1, $2)", tenant.Id, tenant.Name)
+	if err != nil {
+		logger.Get().Warn("Failed to insert tenant.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	invitees := []string{}
+	for _, invitation := range invitations {
+		invitees = append(invitees, invitation.UserAccountId)
+	}
+
+	existing := map[string]bool{}
+	rows, err := tx.Query("SELECT id FROM user_account.user_account WHERE id = ANY($1)", pq.Array(invitees))
+	if err != nil {
+		logger.Get().Warn("Failed to select invited user accounts.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+
+		existing[id] = true
+	}
+
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Everything after the tenant itself is written as the tenant, so row level security applies.
+	err = database.ScopeTenantTx(tx, tenant.Id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO tenant.tenant_user_access (tenant_id, user_account_id, access_level) VALUES ($1, $2, $3)", owner.TenantId, owner.UserAccountId, owner.AccessLevel)
+	if err != nil {
+		logger.Get().Warn("Failed to insert user access.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	for _, invitation := range invitations {
+		if !existing[invitation.UserAccountId] {
+			continue
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO tenant.tenant_invitation (id, tenant_id, user_account_id, access_level, invited_by, status, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+			invitation.Id, invitation.TenantId, invitation.UserAccountId, invitation.AccessLevel, invitation.InvitedBy, invitation.Status, invitation.CreatedAt, invitation.ExpiresAt,
+		)
+		if err != nil {
+			logger.Get().Warn("Failed to insert invitation.", zap.Error(err))
+			tx.Rollback()
+			return err
+		}
+	}
+
+	for _, team := range teams {
+		_, err := tx.Exec(
+			"INSERT INTO team.team (id, tenant_id, name, abbreviation, location, nickname, primary_color, secondary_color, venue, founded_year, active) VALUES ($1, $2, $3, NULLIF(UPPER($4), ''), $5, $6, $7, $8, $9, $10, COALESCE($11, TRUE))",
+			team.Id, tenant.Id, team.Name, team.Abbreviation, team.Location, team.Nickname, team.PrimaryColor, team.SecondaryColor, team.Venue, team.FoundedYear, team.Active,
+		)
+		if err != nil {
+			logger.Get().Warn("Failed to insert team.", zap.Error(err))
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted tenant archive.")
+	return nil
+}
+
+func (r *TenantRepositoryImpl) InsertClone(clone TenantClone) error {
+	logger.Get().Debug("Insert tenant clone.")
+	_, err := r.DB.Exec(
+		"INSERT INTO tenant.tenant_clone (id, source_tenant_id, target_tenant_id, target_name, requested_by, status, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		clone.Id, clone.SourceTenantId, clone.TargetTenantId, clone.TargetName, clone.RequestedBy, clone.Status, clone.CreatedAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert tenant clone.", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *TenantRepositoryImpl) SelectClone(id string) (*TenantClone, error) {
+	logger.Get().Debug("Select tenant clone.")
+	row := r.DB.QueryRow("SELECT id, source_tenant_id, target_tenant_id, target_name, requested_by, status, error, created_at, completed_at FROM tenant.tenant_clone WHERE id = $1", id)
+
+	var clone TenantClone
+	err := row.Scan(&clone.Id, &clone.SourceTenantId, &clone.TargetTenantId, &clone.TargetName, &clone.RequestedBy, &clone.Status, &clone.Error, &clone.CreatedAt, &clone.CompletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Tenant clone not found.")
+			return nil, fmt.Errorf("tenant clone not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found tenant clone.")
+	return &clone, nil
+}
+
+// StartClone moves a pending clone to running, so a redelivered message is not processed twice.
+func (r *TenantRepositoryImpl) StartClone(id string) error {
+	logger.Get().Debug("Start tenant clone.")
+	result, err := r.DB.Exec("UPDATE tenant.tenant_clone SET status = $2 WHERE id = $1 AND status = $3", id, CloneRunning, ClonePending)
+	if err != nil {
+		logger.Get().Warn("Failed to start tenant clone.", zap.Error(err))
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("tenant clone is not pending")
+	}
+
+	return nil
+}
+
+func (r *TenantRepositoryImpl) FinishClone(id string, status TenantCloneStatus, cloneError *string) error {
+	logger.Get().Debug("Finish tenant clone.")
+	_, err := r.DB.Exec("UPDATE tenant.tenant_clone SET status = $2, error = $3, completed_at = NOW() WHERE id = $1", id, status, cloneError)
+	if err != nil {
+		logger.Get().Warn("Failed to finish tenant clone.", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package tenant
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ccthomas/gridiron/pkg/league"
+	"github.com/ccthomas/gridiron/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var DefaultQuotaPolicy = QuotaPolicy{
+	MaxTenantsPerUser: 10,
+	Tenant: TenantQuota{
+		MaxTeams:          500,
+		MaxPlayers:        25000,
+		MaxMembers:        100,
+		MaxApiCallsPerDay: 100000,
+		MaxStorageBytes:   50 * 1024 * 1024,
+	},
+}
+
+// QuotaPolicyFromEnv reads MAX_TENANTS_PER_USER and TENANT_QUOTA_MAX_TEAMS, TENANT_QUOTA_MAX_PLAYERS,
+// TENANT_QUOTA_MAX_MEMBERS, TENANT_QUOTA_MAX_API_CALLS_PER_DAY and TENANT_QUOTA_MAX_STORAGE_BYTES,
+// keeping the defaults for anything unset or invalid. 0 disables a limit.
+func QuotaPolicyFromEnv(defaults QuotaPolicy) QuotaPolicy {
+	policy := defaults
+	policy.MaxTenantsPerUser = limitFromEnv("MAX_TENANTS_PER_USER", defaults.MaxTenantsPerUser)
+	policy.Tenant.MaxTeams = limitFromEnv("TENANT_QUOTA_MAX_TEAMS", defaults.Tenant.MaxTeams)
+	policy.Tenant.MaxPlayers = limitFromEnv("TENANT_QUOTA_MAX_PLAYERS", defaults.Tenant.MaxPlayers)
+	policy.Tenant.MaxMembers = limitFromEnv("TENANT_QUOTA_MAX_MEMBERS", defaults.Tenant.MaxMembers)
+	policy.Tenant.MaxApiCallsPerDay = limitFromEnv("TENANT_QUOTA_MAX_API_CALLS_PER_DAY", defaults.Tenant.MaxApiCallsPerDay)
+	policy.Tenant.MaxStorageBytes = limitFromEnv("TENANT_QUOTA_MAX_STORAGE_BYTES", defaults.Tenant.MaxStorageBytes)
+	return policy
+}
+
+// DefaultRetentionPolicy keeps deleted tenants restorable for 30 days and purges hourly.
+var DefaultRetentionPolicy = RetentionPolicy{
+	Retention:     30 * 24 * time.Hour,
+	PurgeInterval: time.Hour,
+}
+
+// RetentionPolicyFromEnv reads TENANT_RETENTION and TENANT_PURGE_INTERVAL, keeping the defaults for
+// anything unset or invalid. Durations use time.ParseDuration syntax.
+func RetentionPolicyFromEnv(defaults RetentionPolicy) RetentionPolicy {
+	policy := defaults
+	policy.Retention = durationFromEnv("TENANT_RETENTION", defaults.Retention)
+	policy.PurgeInterval = durationFromEnv("TENANT_PURGE_INTERVAL", defaults.PurgeInterval)
+	return policy
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+func limitFromEnv(key string, fallback int64) int64 {
+	limit, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil || limit < 0 {
+		return fallback
+	}
+
+	return limit
+}
+
+// QuotaFor returns the stored quota of the tenant, or the policy's tenant quota when none is stored.
+func (p QuotaPolicy) QuotaFor(repo TenantRepository, tenantId string) TenantQuota {
+	quota, err := repo.SelectQuota(tenantId)
+	if err != nil {
+		logger.Get().Debug("Use default tenant quota.", zap.String("TenantId", tenantId), zap.Error(err))
+		return p.Tenant
+	}
+
+	return *quota
+}
+
+// WithinLimit reports whether adding to used stays within limit. A limit of 0 is unlimited.
+func WithinLimit(limit int64, used int64, adding int64) bool {
+	return limit == 0 || used+adding <= limit
+}
+
+// Validate checks no limit is negative.
+func (q TenantQuota) Validate() bool {
+	return q.MaxTeams >= 0 && q.MaxPlayers >= 0 && q.MaxMembers >= 0 && q.MaxApiCallsPerDay >= 0 && q.MaxStorageBytes >= 0
+}
+
+// Validate checks the archive was written by a compatible schema version and that it can
+// be imported: the tenant is named, access levels are valid, teams are uniquely named and
+// abbreviated, and the team metadata passes league.ValidateTeamMetadata like any other team.
+func (a TenantArchiveDTO) Validate() error {
+	if majorVersion(a.SchemaVersion) != majorVersion(ArchiveSchemaVersion) {
+		return fmt.Errorf("%w: %q", ErrArchiveVersion, a.SchemaVersion)
+	}
+
+	name := strings.TrimSpace(a.Tenant.Name)
+	if name == "" || len(name) > MaxTenantNameLength {
+		return fmt.Errorf("%w: tenant name must be between 1 and %d characters", ErrArchiveInvalid, MaxTenantNameLength)
+	}
+
+	for _, member := range a.Members {
+		if member.UserAccountId == "" || !member.AccessLevel.IsValid() {
+			return fmt.Errorf("%w: member %q is invalid", ErrArchiveInvalid, member.UserAccountId)
+		}
+	}
+
+	ids := map[string]bool{}
+	names := map[string]bool{}
+	abbreviations := map[string]bool{}
+	for _, team := range a.Teams {
+		if team.Id == "" || ids[team.Id] {
+			return fmt.Errorf("%w: team ids must be present and unique", ErrArchiveInvalid)
+		}
+
+		err := league.ValidateTeamMetadata(league.TeamMetadata{
+			Name:         team.Name,
+			Abbreviation: team.Abbreviation,
+			Location:     team.Location,
+			Nickname:     team.Nickname,
+			Venue:        team.Venue,
+			Colors:       []string{team.PrimaryColor, team.SecondaryColor},
+			FoundedYear:  team.FoundedYear,
+		})
+		if err != nil {
+			return fmt.Errorf("%w: team %q: %v", ErrArchiveInvalid, team.Name, err)
+		}
+
+		if names[team.Name] {
+			return fmt.Errorf("%w: team names must be unique", ErrArchiveInvalid)
+		}
+
+		if team.Abbreviation != "" {
+			abbreviation := strings.ToUpper(team.Abbreviation)
+			if abbreviations[abbreviation] {
+				return fmt.Errorf("%w: team abbreviations must be unique", ErrArchiveInvalid)
+			}
+
+			abbreviations[abbreviation] = true
+		}
+
+		ids[team.Id] = true
+		names[team.Name] = true
+	}
+
+	return nil
+}
+
+// storageBytes is the size the archived teams count against the storage quota: the bytes of
+// their text fields, the same columns Team.storageBytes and SelectUsage count.
+func storageBytes(teams []ArchiveTeam) int64 {
+	var bytes int64
+	for _, team := range teams {
+		bytes += int64(len(team.Name) + len(team.Abbreviation) + len(team.Location) + len(team.Nickname) + len(team.PrimaryColor) + len(team.SecondaryColor) + len(team.Venue))
+	}
+
+	return bytes
+}
+
+// truncate shortens s to at most max bytes without splitting a multi-byte character.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	return s[:max]
+}
+
+func majorVersion(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
@@ -1,6 +1,73 @@
 package useracc
 
-import "github.com/ccthomas/gridiron/internal/tenant"
+import (
+	"errors"
+	"time"
+
+	"github.com/ccthomas/gridiron/internal/audit"
+	"github.com/ccthomas/gridiron/internal/tenant"
+	"github.com/ccthomas/gridiron/pkg/notify"
+	"github.com/ccthomas/gridiron/pkg/oidc"
+	"github.com/ccthomas/gridiron/pkg/signing"
+	"github.com/ccthomas/gridiron/pkg/throttle"
+)
+
+// Constants
+
+const (
+	AccessTokenDuration  = 15 * time.Minute
+	RefreshTokenDuration = 30 * 24 * time.Hour
+
+	PasswordResetTokenDuration = time.Hour
+
+	// ReauthenticationWindow is how recently an account without a password must have
+	// signed in to confirm an account deletion.
+	ReauthenticationWindow = 5 * time.Minute
+
+	MaxProfileFieldLength = 255
+
+	MfaIssuer               = "Gridiron"
+	MfaChallengeDuration    = 5 * time.Minute
+	MfaChallengeMaxAttempts = 5
+	RecoveryCodeCount       = 10
+
+	// MaxPasswordLength is in bytes because bcrypt ignores everything past 72 bytes.
+	MinPasswordLength           = 12
+	MaxPasswordLength           = 72
+	MinPasswordCharacterClasses = 3
+)
+
+type PasswordHashAlgorithm string
+
+const (
+	Argon2id PasswordHashAlgorithm = "argon2id"
+	Bcrypt   PasswordHashAlgorithm = "bcrypt"
+)
+
+type LockEventType string
+
+const (
+	AccountLocked   LockEventType = "LOCKED"
+	AccountUnlocked LockEventType = "UNLOCKED"
+)
+
+// Errors
+
+var (
+	ErrRefreshTokenUsed  = errors.New("refresh token already used")
+	ErrEmailTaken        = errors.New("email is taken")
+	ErrInvalidEmail      = errors.New("email is invalid")
+	ErrInvalidTimezone   = errors.New("timezone is invalid")
+	ErrFieldTooLong      = errors.New("profile field is too long")
+	ErrUsernameTaken     = errors.New("username is taken")
+	ErrMfaAlreadyEnabled = errors.New("mfa is already enabled")
+
+	ErrPasswordTooShort         = errors.New("password is too short")
+	ErrPasswordTooLong          = errors.New("password is too long")
+	ErrPasswordTooSimple        = errors.New("password uses too few character classes")
+	ErrPasswordContainsUsername = errors.New("password contains the username")
+	ErrPasswordCommon           = errors.New("password is too common")
+)
 
 // Data Transfer Objects
 
@@ -15,15 +82,189 @@ type UserPassDTO struct {
 }
 
 type LoginResponseDTO struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type PasswordResetRequestDTO struct {
+	Username string `json:"username"`
+}
+
+type PasswordResetConfirmDTO struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type UnlockAccountDTO struct {
+	Username string `json:"username"`
+}
+
+type UserProfileDTO struct {
+	Id           string    `json:"id"`
+	Username     string    `json:"username"`
+	DisplayName  *string   `json:"display_name"`
+	Email        *string   `json:"email"`
+	Timezone     *string   `json:"timezone"`
+	FavoriteTeam *string   `json:"favorite_team"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UpdateProfileDTO holds the profile fields to change. Omitted fields are left
+// unchanged and an empty string clears the field.
+type UpdateProfileDTO struct {
+	DisplayName  *string `json:"display_name"`
+	Email        *string `json:"email"`
+	Timezone     *string `json:"timezone"`
+	FavoriteTeam *string `json:"favorite_team"`
+}
+
+type DeleteAccountDTO struct {
+	Password string `json:"password"`
+}
+
+type OidcAuthorizationDTO struct {
+	AuthorizationUrl string `json:"authorization_url"`
+}
+
+type TotpEnrollmentDTO struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri"`
+}
+
+// MfaCodeDTO holds a TOTP code or, where accepted, a recovery code.
+type MfaCodeDTO struct {
+	Code string `json:"code"`
+}
+
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MfaChallengeDTO is returned by login instead of tokens when the user has MFA enabled.
+type MfaChallengeDTO struct {
+	MfaRequired bool   `json:"mfa_required"`
+	MfaToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type MfaLoginDTO struct {
+	MfaToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type AccountLockEventGetAllDTO struct {
+	Count int                `json:"count"`
+	Data  []AccountLockEvent `json:"data"`
 }
 
 // Entities
 
+// UserAccount is a user. PasswordHash is empty for accounts created by an OIDC login
+// until a password is set.
 type UserAccount struct {
-	Id           string `json:"id"`
-	Username     string `json:"username"`
-	PasswordHash string `json:"password_hash"`
+	Id           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	DisplayName  *string   `json:"display_name"`
+	Email        *string   `json:"email"`
+	Timezone     *string   `json:"timezone"`
+	FavoriteTeam *string   `json:"favorite_team"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type UserSession struct {
+	Id            string     `json:"id"`
+	UserAccountId string     `json:"user_account_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+}
+
+type RefreshToken struct {
+	TokenHash string     `json:"token_hash"`
+	SessionId string     `json:"session_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+type PasswordResetToken struct {
+	TokenHash     string     `json:"token_hash"`
+	UserAccountId string     `json:"user_account_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	UsedAt        *time.Time `json:"used_at"`
+}
+
+// UserMfa is the TOTP enrollment of a user. MFA is enforced once EnabledAt is set.
+type UserMfa struct {
+	UserAccountId string     `json:"user_account_id"`
+	TotpSecret    string     `json:"-"`
+	LastUsedStep  int64      `json:"last_used_step"`
+	CreatedAt     time.Time  `json:"created_at"`
+	EnabledAt     *time.Time `json:"enabled_at"`
+}
+
+// MfaChallenge is issued by login once the password is verified and redeemed with a second factor.
+type MfaChallenge struct {
+	TokenHash     string    `json:"token_hash"`
+	UserAccountId string    `json:"user_account_id"`
+	Attempts      int       `json:"attempts"`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// OidcLoginState is the server side half of an in flight OIDC login. When
+// UserAccountId is set the login links the external identity to that user.
+type OidcLoginState struct {
+	StateHash     string    `json:"state_hash"`
+	Nonce         string    `json:"nonce"`
+	CodeVerifier  string    `json:"code_verifier"`
+	UserAccountId *string   `json:"user_account_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// ExternalIdentity links an identity at an OIDC provider to a user account.
+type ExternalIdentity struct {
+	Issuer        string    `json:"issuer"`
+	Subject       string    `json:"subject"`
+	UserAccountId string    `json:"user_account_id"`
+	Email         *string   `json:"email"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PasswordHashPolicy decides how new password hashes are made. Hashes are encoded with
+// their algorithm and parameters, so hashes made under an older policy still verify and
+// are replaced on the next successful login.
+type PasswordHashPolicy struct {
+	Algorithm        PasswordHashAlgorithm
+	BcryptCost       int
+	Argon2Time       uint32
+	Argon2MemoryKiB  uint32
+	Argon2Threads    uint8
+	Argon2KeyLength  uint32
+	Argon2SaltLength uint32
+}
+
+type AccountLockEvent struct {
+	Id                 string        `json:"id"`
+	Username           string        `json:"username"`
+	Event              LockEventType `json:"event"`
+	ActorUserAccountId *string       `json:"actor_user_account_id"`
+	CreatedAt          time.Time     `json:"created_at"`
 }
 
 // Interfaces
@@ -31,9 +272,45 @@ type UserAccount struct {
 type UserAccountHandlers struct {
 	TenantRepository      tenant.TenantRepository
 	UserAccountRepository UserAccountRepository
+	Notifier              notify.Notifier
+	UserLoginTracker      *throttle.Tracker
+	IpLoginTracker        *throttle.Tracker
+	KeySet                *signing.KeySet
+	OidcProvider          *oidc.Provider
+	AuditRepository       audit.AuditRepository
+	PasswordHashPolicy    PasswordHashPolicy
 }
 
 type UserAccountRepository interface {
 	InsertUserAccount(userAccount UserAccount) error
 	SelectByUsername(username string) (*UserAccount, error)
+	SelectById(id string) (*UserAccount, error)
+	UpdatePasswordHash(id string, passwordHash string) error
+	UpdateProfile(userAccount UserAccount) error
+	DeleteUserAccount(id string) error
+	InsertSession(session UserSession, refreshToken RefreshToken) error
+	SelectSession(id string) (*UserSession, error)
+	RevokeSession(id string) error
+	RevokeSessionsByUser(userId string, exceptSessionId string) error
+	SelectRefreshToken(tokenHash string) (*RefreshToken, error)
+	RotateRefreshToken(usedTokenHash string, refreshToken RefreshToken) error
+	InsertPasswordResetToken(resetToken PasswordResetToken) error
+	SelectPasswordResetTokenUser(tokenHash string) (string, error)
+	ConsumePasswordResetToken(tokenHash string) (string, error)
+	InsertOidcLoginState(state OidcLoginState) error
+	ConsumeOidcLoginState(stateHash string) (*OidcLoginState, error)
+	SelectExternalIdentity(issuer string, subject string) (*ExternalIdentity, error)
+	InsertExternalIdentity(identity ExternalIdentity) error
+	InsertUserAccountWithIdentity(userAccount UserAccount, identity ExternalIdentity) error
+	SelectMfa(userId string) (*UserMfa, error)
+	UpsertPendingMfa(mfa UserMfa) error
+	EnableMfa(userId string, step int64, recoveryCodeHashes []string) error
+	UpdateMfaLastUsedStep(userId string, step int64) (bool, error)
+	ConsumeRecoveryCode(userId string, codeHash string) (bool, error)
+	DeleteMfa(userId string) error
+	InsertMfaChallenge(challenge MfaChallenge) error
+	ClaimMfaChallengeAttempt(tokenHash string, maxAttempts int) (*MfaChallenge, error)
+	DeleteMfaChallenge(tokenHash string) error
+	InsertLockEvent(event AccountLockEvent) error
+	SelectLockEventsByUsername(username string) ([]AccountLockEvent, error)
 }
@@ -1,28 +1,54 @@
 package useracc
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ccthomas/gridiron/internal/audit"
 	"github.com/ccthomas/gridiron/internal/tenant"
 	"github.com/ccthomas/gridiron/pkg/auth"
 	"github.com/ccthomas/gridiron/pkg/logger"
 	"github.com/ccthomas/gridiron/pkg/myhttp"
+	"github.com/ccthomas/gridiron/pkg/notify"
+	"github.com/ccthomas/gridiron/pkg/oidc"
+	"github.com/ccthomas/gridiron/pkg/signing"
+	"github.com/ccthomas/gridiron/pkg/throttle"
+	"github.com/ccthomas/gridiron/pkg/totp"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 // NewHandlers initializes and returns a new Handlers instance
-func NewHandlers(tenantRepository tenant.TenantRepository, userAccountRepository UserAccountRepository) *UserAccountHandlers {
+func NewHandlers(
+	tenantRepository tenant.TenantRepository,
+	userAccountRepository UserAccountRepository,
+	notifier notify.Notifier,
+	userLoginTracker *throttle.Tracker,
+	ipLoginTracker *throttle.Tracker,
+	keySet *signing.KeySet,
+	oidcProvider *oidc.Provider,
+	auditRepository audit.AuditRepository,
+	passwordHashPolicy PasswordHashPolicy,
+) *UserAccountHandlers {
 	logger.Get().Debug("Constructing user account handlers")
 	return &UserAccountHandlers{
 		TenantRepository:      tenantRepository,
 		UserAccountRepository: userAccountRepository,
+		Notifier:              notifier,
+		UserLoginTracker:      userLoginTracker,
+		IpLoginTracker:        ipLoginTracker,
+		KeySet:                keySet,
+		OidcProvider:          oidcProvider,
+		AuditRepository:       auditRepository,
+		PasswordHashPolicy:    passwordHashPolicy,
 	}
 }
 
@@ -38,13 +64,16 @@ func (h *UserAccountHandlers) CreateNewUserHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	logger.Get().Debug("Find user by username.", zap.String("username", userPass.Username))
+	logger.Get().Debug("Check password strength.")
+	if !writePasswordStrengthError(w, ValidatePasswordStrength(userPass.Password, userPass.Username)) {
+		return
+	}
 
 	logger.Get().Debug("Generate id for user.")
 	id := uuid.New().String()
 
 	logger.Get().Debug("Hash password.")
-	hashedPassword, err := HashPassword(userPass.Password)
+	hashedPassword, err := h.PasswordHashPolicy.Hash(userPass.Password)
 	if err != nil {
 		logger.Get().Error("Failed to hash password.")
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
@@ -91,8 +120,8 @@ func (h *UserAccountHandlers) CreateNewUserHandler(w http.ResponseWriter, r *htt
 func (h *UserAccountHandlers) GetAuthorizerContextHandler(w http.ResponseWriter, r *http.Request) {
 	logger.Get().Info("Get Authorizer Context Handler hit.")
 
-	ctx, err := auth.GetAuthorizerContext(r)
-	if err != nil {
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
 		logger.Logger.Debug("Failed to get authorizer context from request")
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
 		return
@@ -101,7 +130,7 @@ func (h *UserAccountHandlers) GetAuthorizerContextHandler(w http.ResponseWriter,
 	logger.Get().Debug("Encode response JSON and write to response.")
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(ctx)
+	err := json.NewEncoder(w).Encode(ctx)
 	if err != nil {
 		logger.Get().Error("Failed to encode authorizer context.")
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
@@ -109,6 +138,22 @@ func (h *UserAccountHandlers) GetAuthorizerContextHandler(w http.ResponseWriter,
 	}
 }
 
+// JwksHandler publishes the public keys access tokens can be verified with.
+func (h *UserAccountHandlers) JwksHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Jwks Handler hit.")
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(h.KeySet.Jwks())
+	if err != nil {
+		logger.Get().Error("Failed to encode jwks.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
 func (h *UserAccountHandlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	username, password, ok := r.BasicAuth()
 	if !ok {
@@ -117,116 +162,1520 @@ func (h *UserAccountHandlers) LoginHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	userKey := fmt.Sprintf("user:%s", username)
+	ipKey := fmt.Sprintf("ip:%s", myhttp.ClientIp(r))
+
+	logger.Get().Debug("Check login attempts are not throttled.")
+	retryAfter := h.UserLoginTracker.RetryAfter(userKey)
+	if ipRetryAfter := h.IpLoginTracker.RetryAfter(ipKey); ipRetryAfter > retryAfter {
+		retryAfter = ipRetryAfter
+	}
+
+	if retryAfter > 0 {
+		logger.Get().Warn("Login attempts throttled.", zap.String("username", username), zap.Duration("RetryAfter", retryAfter))
+		h.recordAudit(r, audit.UserLogin, audit.Denied, nil, username, "too many login attempts")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		myhttp.WriteError(w, http.StatusTooManyRequests, "Too many login attempts. Try again later.")
+		return
+	}
+
 	logger.Get().Debug("Find user by username.", zap.String("username", username))
 	userAccount, err := h.UserAccountRepository.SelectByUsername(username)
 	if err != nil {
 		logger.Get().Warn("Username unknown.")
+		h.recordLoginFailure(username, userKey, ipKey)
+		h.recordAudit(r, audit.UserLogin, audit.Failure, nil, username, "unknown username")
 		myhttp.WriteError(w, http.StatusBadRequest, "Invalid username or password.")
 		return
 	}
 
 	logger.Get().Debug("Check password hash.")
-	match := CheckPasswordHash(password, userAccount.PasswordHash)
+	match, needsRehash := h.PasswordHashPolicy.Verify(password, userAccount.PasswordHash)
 	if !match {
 		logger.Get().Warn("Password does not match.")
+		h.recordLoginFailure(username, userKey, ipKey)
+		h.recordAudit(r, audit.UserLogin, audit.Failure, &userAccount.Id, username, "incorrect password")
 		myhttp.WriteError(w, http.StatusBadRequest, "Invalid username or password.")
 		return
 	}
 
-	logger.Get().Debug("Generate new token.")
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"sub": userAccount.Id,
-			"exp": time.Now().Add(time.Hour * 24).Unix(),
-		})
+	if needsRehash {
+		h.rehashPassword(userAccount.Id, password)
+	}
+
+	logger.Get().Debug("Check if user has mfa enabled.")
+	mfaEnabled, err := h.mfaEnabled(userAccount.Id)
+	if err != nil {
+		logger.Get().Error("Failed to select user mfa.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if mfaEnabled {
+		// The user throttle is only reset once the second factor succeeds, otherwise the
+		// password alone would reset the failed guesses at the code.
+		h.recordAudit(r, audit.UserLogin, audit.Success, &userAccount.Id, username, "mfa required")
+		h.writeMfaChallenge(w, userAccount.Id)
+		return
+	}
+
+	h.UserLoginTracker.Reset(userKey)
+	h.recordAudit(r, audit.UserLogin, audit.Success, &userAccount.Id, username, "")
+	h.startSession(w, userAccount.Id)
+}
 
-	logger.Get().Debug("Fetch secret key.")
-	secretKey := []byte(os.Getenv("SECRET_KEY"))
+// MfaLoginHandler completes a login for a user with MFA enabled by redeeming the
+// challenge token returned by LoginHandler with a TOTP code or a recovery code.
+func (h *UserAccountHandlers) MfaLoginHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Mfa Login Handler hit.")
+
+	var dto MfaLoginDTO
+
+	logger.Get().Debug("Decode mfa login data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.MfaToken == "" || dto.Code == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	tokenHash := auth.HashToken(dto.MfaToken)
+
+	logger.Get().Debug("Claim an attempt at the mfa challenge.")
+	challenge, err := h.UserAccountRepository.ClaimMfaChallengeAttempt(tokenHash, MfaChallengeMaxAttempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Get().Warn("Mfa challenge unknown, expired or exhausted.")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Mfa challenge is invalid or expired.")
+		return
+	}
 
-	logger.Get().Debug("Sign token.")
-	tokenString, err := token.SignedString(secretKey)
 	if err != nil {
-		logger.Get().Error("Cannot sign token.", zap.Error(err))
+		logger.Get().Error("Failed to claim mfa challenge attempt.", zap.Error(err))
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
 		return
 	}
 
-	logger.Get().Debug("Construct response body.")
-	response := &LoginResponseDTO{
-		AccessToken: tokenString,
+	logger.Get().Debug("Find user by id.")
+	userAccount, err := h.UserAccountRepository.SelectById(challenge.UserAccountId)
+	if err != nil {
+		logger.Get().Error("Failed to select user account.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	userKey := fmt.Sprintf("user:%s", userAccount.Username)
+	ipKey := fmt.Sprintf("ip:%s", myhttp.ClientIp(r))
+
+	logger.Get().Debug("Check login attempts are not throttled.")
+	retryAfter := h.UserLoginTracker.RetryAfter(userKey)
+	if ipRetryAfter := h.IpLoginTracker.RetryAfter(ipKey); ipRetryAfter > retryAfter {
+		retryAfter = ipRetryAfter
+	}
+
+	if retryAfter > 0 {
+		logger.Get().Warn("Login attempts throttled.", zap.String("username", userAccount.Username), zap.Duration("RetryAfter", retryAfter))
+		h.recordAudit(r, audit.UserLoginMfa, audit.Denied, &userAccount.Id, userAccount.Username, "too many login attempts")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		myhttp.WriteError(w, http.StatusTooManyRequests, "Too many login attempts. Try again later.")
+		return
+	}
+
+	logger.Get().Debug("Find user mfa.")
+	mfa, err := h.UserAccountRepository.SelectMfa(challenge.UserAccountId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.Get().Error("Failed to select user mfa.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if err != nil || mfa.EnabledAt == nil {
+		logger.Get().Warn("User no longer has mfa enabled.")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Mfa challenge is invalid or expired.")
+		return
+	}
+
+	ok, err := h.verifySecondFactor(mfa, dto.Code)
+	if err != nil {
+		logger.Get().Error("Failed to verify second factor.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if !ok {
+		logger.Get().Warn("Mfa code is invalid.")
+		h.recordLoginFailure(userAccount.Username, userKey, ipKey)
+		h.recordAudit(r, audit.UserLoginMfa, audit.Failure, &challenge.UserAccountId, "", "invalid code")
+		myhttp.WriteError(w, http.StatusBadRequest, "Mfa code is invalid.")
+		return
+	}
+
+	err = h.UserAccountRepository.DeleteMfaChallenge(tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Get().Warn("Mfa challenge was redeemed concurrently.")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Mfa challenge is invalid or expired.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to delete mfa challenge.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.UserLoginTracker.Reset(userKey)
+	h.recordAudit(r, audit.UserLoginMfa, audit.Success, &challenge.UserAccountId, "", "")
+	h.startSession(w, challenge.UserAccountId)
+}
+
+// EnrollTotpHandler starts a TOTP enrollment. MFA is not enforced until the
+// enrollment is confirmed with VerifyTotpHandler.
+func (h *UserAccountHandlers) EnrollTotpHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Enroll Totp Handler hit.")
+
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Find user by id.")
+	userAccount, err := h.UserAccountRepository.SelectById(ctx.UserId)
+	if err != nil {
+		logger.Get().Error("Failed to select user account.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Generate totp secret.")
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logger.Get().Error("Cannot generate totp secret.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Save pending mfa enrollment.")
+	err = h.UserAccountRepository.UpsertPendingMfa(UserMfa{
+		UserAccountId: userAccount.Id,
+		TotpSecret:    secret,
+		CreatedAt:     time.Now().UTC(),
+	})
+	if err == ErrMfaAlreadyEnabled {
+		myhttp.WriteError(w, http.StatusConflict, "Mfa is already enabled.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to save pending mfa enrollment.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	response := &TotpEnrollmentDTO{
+		Secret:          secret,
+		ProvisioningUri: totp.ProvisioningUri(MfaIssuer, userAccount.Username, secret),
 	}
 
 	logger.Get().Debug("Encode response JSON and write to response.")
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
-		logger.Get().Error("Failed to encode user account.")
+		logger.Get().Error("Failed to encode totp enrollment.")
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
 		return
 	}
 }
 
-func (h *UserAccountHandlers) TokenAuthorizerHandler(w http.ResponseWriter, r *http.Request) error {
-	logger.Get().Info("Token Authorizer Handler hit.")
+// VerifyTotpHandler confirms the pending TOTP enrollment with a code from the
+// authenticator, enables MFA and returns a new set of recovery codes.
+func (h *UserAccountHandlers) VerifyTotpHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Verify Totp Handler hit.")
 
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		myhttp.WriteError(w, http.StatusUnauthorized, "Authorization header is missing.")
-		return fmt.Errorf("authorization header is missing")
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
 	}
 
-	tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+	var dto MfaCodeDTO
+
+	logger.Get().Debug("Decode mfa code data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.Code == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
 
-	logger.Get().Debug("Fetch secret key.")
-	secretKey := []byte(os.Getenv("SECRET_KEY"))
+	logger.Get().Debug("Find user mfa.")
+	mfa, err := h.UserAccountRepository.SelectMfa(ctx.UserId)
+	if err != nil {
+		myhttp.WriteError(w, http.StatusBadRequest, "Mfa enrollment has not been started.")
+		return
+	}
 
-	logger.Get().Debug("Parse token.")
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return secretKey, nil
-	})
+	if mfa.EnabledAt != nil {
+		myhttp.WriteError(w, http.StatusConflict, "Mfa is already enabled.")
+		return
+	}
+
+	step, ok := totp.Validate(mfa.TotpSecret, dto.Code, time.Now())
+	if !ok {
+		logger.Get().Warn("Totp code is invalid.")
+		myhttp.WriteError(w, http.StatusBadRequest, "Mfa code is invalid.")
+		return
+	}
+
+	logger.Get().Debug("Generate recovery codes.")
+	recoveryCodes := make([]string, RecoveryCodeCount)
+	recoveryCodeHashes := make([]string, RecoveryCodeCount)
+	for i := range recoveryCodes {
+		recoveryCodes[i], err = GenerateRecoveryCode()
+		if err != nil {
+			logger.Get().Error("Cannot generate recovery code.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+
+		recoveryCodeHashes[i] = HashRecoveryCode(recoveryCodes[i])
+	}
+
+	logger.Get().Debug("Enable mfa.")
+	err = h.UserAccountRepository.EnableMfa(ctx.UserId, step, recoveryCodeHashes)
+	if err == ErrMfaAlreadyEnabled {
+		myhttp.WriteError(w, http.StatusConflict, "Mfa is already enabled.")
+		return
+	}
 
 	if err != nil {
-		logger.Get().Warn("Failed to parse token.")
+		logger.Get().Error("Failed to enable mfa.", zap.Error(err))
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
-		return err
+		return
 	}
 
-	if !token.Valid {
-		logger.Get().Warn("Token was invalid.")
-		myhttp.WriteError(w, http.StatusUnauthorized, "Authorization header is missing.")
-		return fmt.Errorf("token is not valid")
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(&RecoveryCodesDTO{RecoveryCodes: recoveryCodes})
+	if err != nil {
+		logger.Get().Error("Failed to encode recovery codes.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
 	}
+}
 
-	logger.Get().Debug("Get claims from token.", zap.Any("Token", token))
-	claims := token.Claims.(jwt.MapClaims)
-	id := claims["sub"].(string)
+// DisableTotpHandler turns MFA off once the caller proves possession of a second factor.
+func (h *UserAccountHandlers) DisableTotpHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Disable Totp Handler hit.")
 
-	userAccess, err := h.TenantRepository.SelectTenantAccessByUser(id)
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto MfaCodeDTO
+
+	logger.Get().Debug("Decode mfa code data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.Code == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	logger.Get().Debug("Find user mfa.")
+	mfa, err := h.UserAccountRepository.SelectMfa(ctx.UserId)
+	if err != nil || mfa.EnabledAt == nil {
+		myhttp.WriteError(w, http.StatusBadRequest, "Mfa is not enabled.")
+		return
+	}
+
+	ok, err = h.verifySecondFactor(mfa, dto.Code)
 	if err != nil {
-		logger.Logger.Error("Failed to select tenant user access by user.", zap.Error(err))
+		logger.Get().Error("Failed to verify second factor.", zap.Error(err))
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
-		return err
+		return
 	}
 
-	// Create a map keyed by Tenant Id with the value of AccessLevel
-	accessMap := make(map[string]auth.AccessLevel)
-	for _, access := range userAccess {
-		accessMap[access.TenantId] = access.AccessLevel
+	if !ok {
+		logger.Get().Warn("Mfa code is invalid.")
+		myhttp.WriteError(w, http.StatusBadRequest, "Mfa code is invalid.")
+		return
 	}
 
-	logger.Get().Debug("JSON encode authorizer context")
-	b, err := json.Marshal(auth.AuthorizerContext{
-		UserId:       id,
-		TenantAccess: accessMap,
-	})
+	logger.Get().Debug("Delete mfa.")
+	err = h.UserAccountRepository.DeleteMfa(ctx.UserId)
+	if err != nil {
+		logger.Get().Error("Failed to delete mfa.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// OidcLoginHandler starts an OIDC login by redirecting the browser to the identity provider.
+func (h *UserAccountHandlers) OidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Oidc Login Handler hit.")
+
+	authorizationUrl, ok := h.beginOidcLogin(w, nil)
+	if !ok {
+		return
+	}
+
+	http.Redirect(w, r, authorizationUrl, http.StatusFound)
+}
+
+// OidcLinkHandler starts an OIDC login that links the external identity to the caller's account.
+func (h *UserAccountHandlers) OidcLinkHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Oidc Link Handler hit.")
 
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	authorizationUrl, ok := h.beginOidcLogin(w, &ctx.UserId)
+	if !ok {
+		return
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(&OidcAuthorizationDTO{AuthorizationUrl: authorizationUrl})
+	if err != nil {
+		logger.Get().Error("Failed to encode authorization url.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// OidcCallbackHandler completes an OIDC login. The authorization code is exchanged using
+// the PKCE verifier, the id token is verified, and the external identity is resolved to
+// a user account, creating one on first login, before Gridiron tokens are issued.
+func (h *UserAccountHandlers) OidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Oidc Callback Handler hit.")
+
+	if h.OidcProvider == nil {
+		myhttp.WriteError(w, http.StatusNotFound, "OIDC login is not configured.")
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		logger.Get().Warn("Identity provider returned an error.", zap.String("Error", query.Get("error")))
+		myhttp.WriteError(w, http.StatusBadRequest, "Invalid OIDC login.")
+		return
+	}
+
+	code := query.Get("code")
+	stateToken := query.Get("state")
+	if code == "" || stateToken == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "Invalid OIDC login.")
+		return
+	}
+
+	logger.Get().Debug("Consume oidc login state.")
+	state, err := h.UserAccountRepository.ConsumeOidcLoginState(auth.HashToken(stateToken))
+	if err != nil {
+		logger.Get().Warn("Oidc login state unknown or expired.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "Invalid OIDC login.")
+		return
+	}
+
+	logger.Get().Debug("Exchange authorization code.")
+	tokenResponse, err := h.OidcProvider.Exchange(code, state.CodeVerifier)
 	if err != nil {
-		logger.Get().Warn("Failed to encode context.")
+		logger.Get().Warn("Failed to exchange authorization code.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "Invalid OIDC login.")
+		return
+	}
+
+	logger.Get().Debug("Verify id token.")
+	claims, err := h.OidcProvider.VerifyIdToken(tokenResponse.IdToken, state.Nonce)
+	if err != nil {
+		logger.Get().Warn("Failed to verify id token.", zap.Error(err))
+		h.recordAudit(r, audit.UserLoginOidc, audit.Failure, state.UserAccountId, "", "id token is invalid")
+		myhttp.WriteError(w, http.StatusBadRequest, "Invalid OIDC login.")
+		return
+	}
+
+	logger.Get().Debug("Find external identity.", zap.String("Issuer", claims.Issuer), zap.String("Subject", claims.Subject))
+	identity, err := h.UserAccountRepository.SelectExternalIdentity(claims.Issuer, claims.Subject)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.Get().Error("Failed to select external identity.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if state.UserAccountId != nil {
+		if identity != nil && identity.UserAccountId != *state.UserAccountId {
+			logger.Get().Warn("External identity is linked to another user.")
+			myhttp.WriteError(w, http.StatusConflict, "External identity is already linked to another user.")
+			return
+		}
+
+		if identity == nil {
+			logger.Get().Debug("Link external identity.")
+			err = h.UserAccountRepository.InsertExternalIdentity(newExternalIdentity(claims, *state.UserAccountId))
+			if err != nil {
+				logger.Get().Error("Failed to insert external identity.", zap.Error(err))
+				myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+				return
+			}
+		}
+
+		h.completeOidcLogin(w, r, *state.UserAccountId, claims.Issuer, "linked")
+		return
+	}
+
+	if identity != nil {
+		h.completeOidcLogin(w, r, identity.UserAccountId, claims.Issuer, "")
+		return
+	}
+
+	logger.Get().Debug("Create user account for external identity.")
+	userAccount, err := h.createOidcUserAccount(claims)
+	if err != nil {
+		logger.Get().Error("Failed to create user account for external identity.", zap.Error(err))
 		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
-		return err
+		return
+	}
+
+	h.completeOidcLogin(w, r, userAccount.Id, claims.Issuer, "created")
+}
+
+func (h *UserAccountHandlers) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Refresh Token Handler hit.")
+
+	var dto RefreshTokenDTO
+
+	logger.Get().Debug("Decode refresh token data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.RefreshToken == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
 	}
 
-	logger.Get().Debug("Set request context on request header.")
-	r.Header.Set("request-context", string(b))
-	return nil
+	logger.Get().Debug("Find refresh token.")
+	usedTokenHash := auth.HashToken(dto.RefreshToken)
+	stored, err := h.UserAccountRepository.SelectRefreshToken(usedTokenHash)
+	if err != nil {
+		logger.Get().Warn("Refresh token unknown.")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Invalid refresh token.")
+		return
+	}
+
+	if stored.UsedAt != nil {
+		// A refresh token is only valid once. Seeing it again means it was likely stolen,
+		// so the whole session is revoked to lock out both parties.
+		logger.Get().Warn("Refresh token reused, revoking session.", zap.String("SessionId", stored.SessionId))
+		h.revokeSession(stored.SessionId)
+		myhttp.WriteError(w, http.StatusUnauthorized, "Invalid refresh token.")
+		return
+	}
+
+	logger.Get().Debug("Find session.")
+	session, err := h.UserAccountRepository.SelectSession(stored.SessionId)
+	if err != nil {
+		logger.Get().Error("Failed to select session.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	now := time.Now().UTC()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) || now.After(stored.ExpiresAt) {
+		logger.Get().Warn("Session is revoked or expired.")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Invalid refresh token.")
+		return
+	}
+
+	logger.Get().Debug("Generate refresh token.")
+	refreshToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		logger.Get().Error("Cannot generate refresh token.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Rotate refresh token.")
+	err = h.UserAccountRepository.RotateRefreshToken(usedTokenHash, RefreshToken{
+		TokenHash: auth.HashToken(refreshToken),
+		SessionId: session.Id,
+		CreatedAt: now,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err == ErrRefreshTokenUsed {
+		logger.Get().Warn("Refresh token reused, revoking session.", zap.String("SessionId", session.Id))
+		h.revokeSession(session.Id)
+		myhttp.WriteError(w, http.StatusUnauthorized, "Invalid refresh token.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to rotate refresh token.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.writeTokenResponse(w, session.UserAccountId, session.Id, refreshToken)
+}
+
+func (h *UserAccountHandlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Logout Handler hit.")
+
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Revoke session.", zap.String("SessionId", ctx.SessionId))
+	err := h.UserAccountRepository.RevokeSession(ctx.SessionId)
+	if err != nil {
+		logger.Get().Error("Failed to revoke session.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAccountHandlers) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Change Password Handler hit.")
+
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto ChangePasswordDTO
+
+	logger.Get().Debug("Decode change password data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.NewPassword == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	logger.Get().Debug("Find user by id.")
+	userAccount, err := h.UserAccountRepository.SelectById(ctx.UserId)
+	if err != nil {
+		logger.Get().Error("Failed to select user account.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	// An account created by an OIDC login sets its first password without a current one.
+	firstPassword := !HasPassword(userAccount)
+
+	if !firstPassword {
+		logger.Get().Debug("Check current password hash.")
+		if match, _ := h.PasswordHashPolicy.Verify(dto.CurrentPassword, userAccount.PasswordHash); !match {
+			logger.Get().Warn("Current password does not match.")
+			h.recordAudit(r, audit.UserPasswordChange, audit.Failure, nil, "", "incorrect current password")
+			myhttp.WriteError(w, http.StatusBadRequest, "Current password is incorrect.")
+			return
+		}
+	}
+
+	logger.Get().Debug("Check new password strength.")
+	if !writePasswordStrengthError(w, ValidatePasswordStrength(dto.NewPassword, userAccount.Username)) {
+		return
+	}
+
+	if !h.updatePassword(w, userAccount.Id, dto.NewPassword) {
+		return
+	}
+
+	logger.Get().Debug("Revoke other sessions.")
+	err = h.UserAccountRepository.RevokeSessionsByUser(userAccount.Id, ctx.SessionId)
+	if err != nil {
+		logger.Get().Error("Failed to revoke other sessions.", zap.Error(err))
+	}
+
+	detail := ""
+	if firstPassword {
+		detail = "first password"
+	}
+
+	h.recordAudit(r, audit.UserPasswordChange, audit.Success, nil, "", detail)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAccountHandlers) GetProfileHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get Profile Handler hit.")
+
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Find user by id.")
+	userAccount, err := h.UserAccountRepository.SelectById(ctx.UserId)
+	if err != nil {
+		logger.Get().Error("Failed to select user account.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	writeProfileResponse(w, userAccount)
+}
+
+func (h *UserAccountHandlers) UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Update Profile Handler hit.")
+
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto UpdateProfileDTO
+
+	logger.Get().Debug("Decode update profile data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	logger.Get().Debug("Find user by id.")
+	userAccount, err := h.UserAccountRepository.SelectById(ctx.UserId)
+	if err != nil {
+		logger.Get().Error("Failed to select user account.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Apply profile update.")
+	err = ApplyProfileUpdate(userAccount, dto)
+	switch err {
+	case nil:
+	case ErrInvalidEmail:
+		myhttp.WriteError(w, http.StatusBadRequest, "Email is invalid.")
+		return
+	case ErrInvalidTimezone:
+		myhttp.WriteError(w, http.StatusBadRequest, "Timezone is invalid.")
+		return
+	case ErrFieldTooLong:
+		myhttp.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Profile fields must be at most %d characters.", MaxProfileFieldLength))
+		return
+	default:
+		logger.Get().Error("Failed to apply profile update.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	userAccount.UpdatedAt = time.Now().UTC()
+
+	logger.Get().Debug("Save profile.")
+	err = h.UserAccountRepository.UpdateProfile(*userAccount)
+	if err == ErrEmailTaken {
+		myhttp.WriteError(w, http.StatusBadRequest, "Email is taken.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to update profile.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	writeProfileResponse(w, userAccount)
+}
+
+// DeleteAccountHandler deletes the caller's account once they confirm their password.
+// Accounts without a password confirm by having signed in within ReauthenticationWindow.
+// Accounts that are the only owner of a tenant must hand over or delete those tenants first.
+func (h *UserAccountHandlers) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Delete Account Handler hit.")
+
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto DeleteAccountDTO
+
+	logger.Get().Debug("Decode delete account data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	logger.Get().Debug("Find user by id.")
+	userAccount, err := h.UserAccountRepository.SelectById(ctx.UserId)
+	if err != nil {
+		logger.Get().Error("Failed to select user account.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if HasPassword(userAccount) {
+		logger.Get().Debug("Check password hash.")
+		if match, _ := h.PasswordHashPolicy.Verify(dto.Password, userAccount.PasswordHash); !match {
+			logger.Get().Warn("Password does not match.")
+			myhttp.WriteError(w, http.StatusBadRequest, "Password is incorrect.")
+			return
+		}
+	} else {
+		logger.Get().Debug("Check session is recent.")
+		session, err := h.UserAccountRepository.SelectSession(ctx.SessionId)
+		if err != nil || time.Since(session.CreatedAt) > ReauthenticationWindow {
+			logger.Get().Warn("Session is too old to delete an account without a password.")
+			myhttp.WriteError(w, http.StatusBadRequest, "Sign in again to delete the account.")
+			return
+		}
+	}
+
+	logger.Get().Debug("Check user is not the sole owner of a tenant.")
+	tenants, err := h.TenantRepository.SelectSoleOwnedTenantsByUser(userAccount.Id)
+	if err != nil {
+		logger.Get().Error("Failed to select sole owned tenants.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if len(tenants) > 0 {
+		logger.Get().Warn("User is the sole owner of tenants.", zap.Int("Count", len(tenants)))
+		myhttp.WriteError(w, http.StatusConflict, "User is the sole owner of one or more tenants.")
+		return
+	}
+
+	logger.Get().Debug("Delete user account.")
+	err = h.UserAccountRepository.DeleteUserAccount(userAccount.Id)
+	if err != nil {
+		logger.Get().Error("Failed to delete user account.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAccountHandlers) PasswordResetRequestHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Password Reset Request Handler hit.")
+
+	var dto PasswordResetRequestDTO
+
+	logger.Get().Debug("Decode password reset request data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.Username == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	// The response is the same whether or not the user exists so
+	// the endpoint cannot be used to discover usernames.
+	userAccount, err := h.UserAccountRepository.SelectByUsername(dto.Username)
+	if err != nil {
+		logger.Get().Warn("Password reset requested for unknown username.")
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	logger.Get().Debug("Generate password reset token.")
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		logger.Get().Error("Cannot generate password reset token.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	now := time.Now().UTC()
+	err = h.UserAccountRepository.InsertPasswordResetToken(PasswordResetToken{
+		TokenHash:     auth.HashToken(token),
+		UserAccountId: userAccount.Id,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(PasswordResetTokenDuration),
+	})
+	if err != nil {
+		logger.Get().Error("Failed to insert password reset token.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Send password reset notification.")
+	err = h.Notifier.Send(notify.Message{
+		Recipient: userAccount.Username,
+		Subject:   "Reset your Gridiron password",
+		Body:      fmt.Sprintf("Use this token to reset your password within the next hour: %s", token),
+	})
+	if err != nil {
+		logger.Get().Error("Failed to send password reset notification.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *UserAccountHandlers) PasswordResetConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Password Reset Confirm Handler hit.")
+
+	var dto PasswordResetConfirmDTO
+
+	logger.Get().Debug("Decode password reset confirm data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.Token == "" || dto.NewPassword == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	tokenHash := auth.HashToken(dto.Token)
+
+	logger.Get().Debug("Find password reset token user.")
+	userId, err := h.UserAccountRepository.SelectPasswordResetTokenUser(tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Get().Warn("Password reset token is invalid.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "Invalid or expired reset token.")
+		return
+	}
+
+	if err != nil {
+		logger.Get().Error("Failed to find password reset token.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Find user by id.")
+	userAccount, err := h.UserAccountRepository.SelectById(userId)
+	if err != nil {
+		logger.Get().Error("Failed to find user account.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Check new password strength.")
+	if !writePasswordStrengthError(w, ValidatePasswordStrength(dto.NewPassword, userAccount.Username)) {
+		return
+	}
+
+	logger.Get().Debug("Consume password reset token.")
+	userId, err = h.UserAccountRepository.ConsumePasswordResetToken(tokenHash)
+	if err != nil {
+		logger.Get().Warn("Password reset token is invalid.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadRequest, "Invalid or expired reset token.")
+		return
+	}
+
+	if !h.updatePassword(w, userId, dto.NewPassword) {
+		return
+	}
+
+	logger.Get().Debug("Revoke all sessions.")
+	err = h.UserAccountRepository.RevokeSessionsByUser(userId, "")
+	if err != nil {
+		logger.Get().Error("Failed to revoke sessions.", zap.Error(err))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAccountHandlers) UnlockAccountHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Unlock Account Handler hit.")
+
+	ctx, ok := auth.FromContext(r.Context())
+	if !ok {
+		logger.Logger.Debug("Failed to get authorizer context from request")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var dto UnlockAccountDTO
+
+	logger.Get().Debug("Decode unlock account data.")
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil || dto.Username == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "payload provided was invalid.")
+		return
+	}
+
+	logger.Get().Debug("Reset login attempts.", zap.String("username", dto.Username))
+	h.UserLoginTracker.Reset(fmt.Sprintf("user:%s", dto.Username))
+
+	err = h.UserAccountRepository.InsertLockEvent(AccountLockEvent{
+		Id:                 uuid.New().String(),
+		Username:           dto.Username,
+		Event:              AccountUnlocked,
+		ActorUserAccountId: &ctx.UserId,
+		CreatedAt:          time.Now().UTC(),
+	})
+	if err != nil {
+		logger.Get().Error("Failed to insert unlock event.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAccountHandlers) GetLockEventsHandler(w http.ResponseWriter, r *http.Request) {
+	logger.Get().Info("Get Lock Events Handler hit.")
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		myhttp.WriteError(w, http.StatusBadRequest, "username query parameter is missing.")
+		return
+	}
+
+	events, err := h.UserAccountRepository.SelectLockEventsByUsername(username)
+	if err != nil {
+		logger.Logger.Error("Failed to select lock events.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	var jsonResponse []byte = []byte(`{"count":0,"data":[]}`)
+	if len(events) != 0 {
+		jsonResponse, err = json.Marshal(&AccountLockEventGetAllDTO{
+			Count: len(events),
+			Data:  events,
+		})
+		if err != nil {
+			logger.Logger.Error("Failed to marshal response.", zap.Error(err))
+			myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+			return
+		}
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (h *UserAccountHandlers) TokenAuthorizerHandler(w http.ResponseWriter, r *http.Request) (*http.Request, error) {
+	logger.Get().Info("Token Authorizer Handler hit.")
+
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		myhttp.WriteError(w, http.StatusUnauthorized, "Authorization header is missing.")
+		return nil, fmt.Errorf("authorization header is missing")
+	}
+
+	tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+
+	logger.Get().Debug("Parse token.")
+	token, err := h.KeySet.Parse(tokenString)
+
+	if err != nil {
+		logger.Get().Warn("Failed to parse token.", zap.Error(err))
+		h.recordAudit(r, audit.UserToken, audit.Failure, nil, "", err.Error())
+		myhttp.WriteError(w, http.StatusUnauthorized, "Token is invalid.")
+		return nil, err
+	}
+
+	if !token.Valid {
+		logger.Get().Warn("Token was invalid.")
+		h.recordAudit(r, audit.UserToken, audit.Failure, nil, "", "token is not valid")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Authorization header is missing.")
+		return nil, fmt.Errorf("token is not valid")
+	}
+
+	logger.Get().Debug("Get claims from token.", zap.Any("Token", token))
+	claims := token.Claims.(jwt.MapClaims)
+	id, ok := claims["sub"].(string)
+	if !ok {
+		logger.Get().Warn("Token is missing a subject.")
+		h.recordAudit(r, audit.UserToken, audit.Failure, nil, "", "token is missing subject")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Token is invalid.")
+		return nil, fmt.Errorf("token is missing subject")
+	}
+
+	sessionId, ok := claims["sid"].(string)
+	if !ok {
+		logger.Get().Warn("Token is not bound to a session.")
+		h.recordAudit(r, audit.UserToken, audit.Failure, &id, "", "token is missing session id")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Token is invalid.")
+		return nil, fmt.Errorf("token is missing session id")
+	}
+
+	tokenId, _ := claims["jti"].(string)
+
+	logger.Get().Debug("Check session has not been revoked.")
+	session, err := h.UserAccountRepository.SelectSession(sessionId)
+	if err != nil {
+		logger.Get().Warn("Failed to select session.", zap.Error(err))
+		h.recordAudit(r, audit.UserToken, audit.Failure, &id, sessionId, "session not found")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Token is invalid.")
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || session.UserAccountId != id {
+		logger.Get().Warn("Session has been revoked.", zap.String("SessionId", sessionId))
+		h.recordAudit(r, audit.UserToken, audit.Failure, &id, sessionId, "session has been revoked")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Token has been revoked.")
+		return nil, fmt.Errorf("session has been revoked")
+	}
+
+	if time.Now().UTC().After(session.ExpiresAt) {
+		logger.Get().Warn("Session has expired.", zap.String("SessionId", sessionId))
+		h.recordAudit(r, audit.UserToken, audit.Failure, &id, sessionId, "session has expired")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Token has expired.")
+		return nil, fmt.Errorf("session has expired")
+	}
+
+	userAccess, err := h.TenantRepository.SelectTenantAccessByUser(id)
+	if err != nil {
+		logger.Logger.Error("Failed to select tenant user access by user.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return nil, err
+	}
+
+	// Create a map keyed by Tenant Id with the value of AccessLevel
+	accessMap := make(map[string]auth.AccessLevel)
+	for _, access := range userAccess {
+		accessMap[access.TenantId] = access.AccessLevel
+	}
+
+	return withAuthorizerContext(r, auth.AuthorizerContext{
+		UserId:       id,
+		SessionId:    sessionId,
+		TokenId:      tokenId,
+		TenantAccess: accessMap,
+	})
+}
+
+// ApiKeyAuthorizerHandler authorizes a request made with a tenant api key. The key acts
+// on behalf of the user who created it, limited to the key's tenant and scopes.
+func (h *UserAccountHandlers) ApiKeyAuthorizerHandler(w http.ResponseWriter, r *http.Request) (*http.Request, error) {
+	logger.Get().Info("Api Key Authorizer Handler hit.")
+
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		myhttp.WriteError(w, http.StatusUnauthorized, "Api key is invalid.")
+		return nil, fmt.Errorf("api key header is missing")
+	}
+
+	logger.Get().Debug("Find api key.")
+	apiKey, err := h.TenantRepository.SelectApiKeyByHash(auth.HashToken(key))
+	if err != nil {
+		logger.Get().Warn("Api key unknown.")
+		h.recordAudit(r, audit.UserApiKey, audit.Failure, nil, "", "unknown api key")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Api key is invalid.")
+		return nil, err
+	}
+
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && time.Now().UTC().After(*apiKey.ExpiresAt)) {
+		logger.Get().Warn("Api key is revoked or expired.", zap.String("ApiKeyId", apiKey.Id))
+		h.recordAudit(r, audit.UserApiKey, audit.Failure, &apiKey.CreatedBy, apiKey.Id, "api key is revoked or expired")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Api key is invalid.")
+		return nil, fmt.Errorf("api key is revoked or expired")
+	}
+
+	logger.Get().Debug("Check api key creator still has access to tenant.")
+	access, err := h.TenantRepository.SelectUserAccess(apiKey.TenantId, apiKey.CreatedBy)
+	if err != nil {
+		logger.Get().Warn("Api key creator no longer has access to tenant.", zap.String("ApiKeyId", apiKey.Id))
+		h.recordAudit(r, audit.UserApiKey, audit.Failure, &apiKey.CreatedBy, apiKey.Id, "api key creator no longer has access to tenant")
+		myhttp.WriteError(w, http.StatusUnauthorized, "Api key is invalid.")
+		return nil, err
+	}
+
+	err = h.TenantRepository.UpdateApiKeyLastUsed(apiKey.Id)
+	if err != nil {
+		logger.Get().Error("Failed to update api key last used.", zap.Error(err))
+	}
+
+	return withAuthorizerContext(r, auth.AuthorizerContext{
+		UserId:   apiKey.CreatedBy,
+		ApiKeyId: apiKey.Id,
+		Scopes:   apiKey.Scopes,
+		TenantAccess: map[string]auth.AccessLevel{
+			apiKey.TenantId: access.AccessLevel,
+		},
+	})
+}
+
+// updatePassword hashes and stores the new password. On failure the error
+// response has already been written.
+func (h *UserAccountHandlers) updatePassword(w http.ResponseWriter, userId string, password string) bool {
+	logger.Get().Debug("Hash password.")
+	hashedPassword, err := h.PasswordHashPolicy.Hash(password)
+	if err != nil {
+		logger.Get().Error("Failed to hash password.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return false
+	}
+
+	logger.Get().Debug("Save password hash.")
+	err = h.UserAccountRepository.UpdatePasswordHash(userId, hashedPassword)
+	if err != nil {
+		logger.Get().Error("Failed to update password hash.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return false
+	}
+
+	return true
+}
+
+// rehashPassword replaces a password hash made under an older policy. Failure is
+// logged and the old hash keeps working.
+func (h *UserAccountHandlers) rehashPassword(userId string, password string) {
+	logger.Get().Debug("Rehash password under current policy.")
+	hashedPassword, err := h.PasswordHashPolicy.Hash(password)
+	if err != nil {
+		logger.Get().Error("Failed to rehash password.", zap.Error(err))
+		return
+	}
+
+	err = h.UserAccountRepository.UpdatePasswordHash(userId, hashedPassword)
+	if err != nil {
+		logger.Get().Error("Failed to update rehashed password.", zap.Error(err))
+	}
+}
+
+// recordLoginFailure counts a failed login against the username and ip address,
+// recording a lock event when the failure locks the account.
+func (h *UserAccountHandlers) recordLoginFailure(username string, userKey string, ipKey string) {
+	h.IpLoginTracker.Fail(ipKey)
+
+	if !h.UserLoginTracker.Fail(userKey) {
+		return
+	}
+
+	logger.Get().Warn("Account locked.", zap.String("username", username))
+	err := h.UserAccountRepository.InsertLockEvent(AccountLockEvent{
+		Id:        uuid.New().String(),
+		Username:  username,
+		Event:     AccountLocked,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		logger.Get().Error("Failed to insert lock event.", zap.Error(err))
+	}
+}
+
+// recordAudit records an authentication event. The actor defaults to the caller
+// in the request context when actorId is nil.
+func (h *UserAccountHandlers) recordAudit(r *http.Request, action audit.Action, outcome audit.Outcome, actorId *string, target string, detail string) {
+	audit.Record(h.AuditRepository, r, audit.AuditEvent{
+		ActorUserAccountId: actorId,
+		Action:             action,
+		Outcome:            outcome,
+		Target:             audit.String(target),
+		Detail:             audit.String(detail),
+	})
+}
+
+func (h *UserAccountHandlers) revokeSession(sessionId string) {
+	err := h.UserAccountRepository.RevokeSession(sessionId)
+	if err != nil {
+		logger.Get().Error("Failed to revoke session.", zap.Error(err))
+	}
+}
+
+// beginOidcLogin stores the state, nonce and PKCE verifier for a new OIDC login and
+// returns the provider url to send the user to. On failure the error response has
+// already been written.
+func (h *UserAccountHandlers) beginOidcLogin(w http.ResponseWriter, userId *string) (string, bool) {
+	if h.OidcProvider == nil {
+		myhttp.WriteError(w, http.StatusNotFound, "OIDC login is not configured.")
+		return "", false
+	}
+
+	logger.Get().Debug("Generate oidc state, nonce and pkce verifier.")
+	stateToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		logger.Get().Error("Cannot generate oidc state.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return "", false
+	}
+
+	nonce, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		logger.Get().Error("Cannot generate oidc nonce.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return "", false
+	}
+
+	codeVerifier, codeChallenge, err := oidc.GeneratePkce()
+	if err != nil {
+		logger.Get().Error("Cannot generate pkce verifier.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return "", false
+	}
+
+	logger.Get().Debug("Build authorization url.")
+	authorizationUrl, err := h.OidcProvider.AuthCodeUrl(stateToken, nonce, codeChallenge)
+	if err != nil {
+		logger.Get().Error("Failed to build authorization url.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusBadGateway, "Identity provider is unavailable.")
+		return "", false
+	}
+
+	logger.Get().Debug("Save oidc login state.")
+	now := time.Now().UTC()
+	err = h.UserAccountRepository.InsertOidcLoginState(OidcLoginState{
+		StateHash:     auth.HashToken(stateToken),
+		Nonce:         nonce,
+		CodeVerifier:  codeVerifier,
+		UserAccountId: userId,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(oidc.LoginStateDuration),
+	})
+	if err != nil {
+		logger.Get().Error("Failed to insert oidc login state.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return "", false
+	}
+
+	return authorizationUrl, true
+}
+
+// createOidcUserAccount creates an account for a first time OIDC login. The account has
+// no usable password, so it can only log in through its identity provider.
+func (h *UserAccountHandlers) createOidcUserAccount(claims *oidc.IdTokenClaims) (*UserAccount, error) {
+	userAccount := UserAccount{
+		Id:       uuid.New().String(),
+		Username: OidcUsername(claims),
+	}
+
+	if claims.Name != "" {
+		userAccount.DisplayName = &claims.Name
+	}
+
+	identity := newExternalIdentity(claims, userAccount.Id)
+
+	err := h.UserAccountRepository.InsertUserAccountWithIdentity(userAccount, identity)
+	if err == ErrUsernameTaken {
+		logger.Get().Debug("Username taken, retry with suffix.", zap.String("Username", userAccount.Username))
+		userAccount.Username = fmt.Sprintf("%s-%s", userAccount.Username, userAccount.Id[:8])
+		err = h.UserAccountRepository.InsertUserAccountWithIdentity(userAccount, identity)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &userAccount, nil
+}
+
+func newExternalIdentity(claims *oidc.IdTokenClaims, userId string) ExternalIdentity {
+	identity := ExternalIdentity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		UserAccountId: userId,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if claims.Email != "" {
+		identity.Email = &claims.Email
+	}
+
+	return identity
+}
+
+// verifySecondFactor checks a TOTP code, rejecting codes already used, and
+// falls back to consuming a recovery code.
+func (h *UserAccountHandlers) verifySecondFactor(mfa *UserMfa, code string) (bool, error) {
+	if step, ok := totp.Validate(mfa.TotpSecret, code, time.Now()); ok {
+		logger.Get().Debug("Totp code matched, check it has not been used.")
+		return h.UserAccountRepository.UpdateMfaLastUsedStep(mfa.UserAccountId, step)
+	}
+
+	logger.Get().Debug("Try recovery code.")
+	return h.UserAccountRepository.ConsumeRecoveryCode(mfa.UserAccountId, HashRecoveryCode(code))
+}
+
+// mfaEnabled reports whether the user has a confirmed MFA enrollment. A user without
+// an enrollment is not an error.
+func (h *UserAccountHandlers) mfaEnabled(userId string) (bool, error) {
+	mfa, err := h.UserAccountRepository.SelectMfa(userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return mfa.EnabledAt != nil, nil
+}
+
+// completeOidcLogin starts a session for a user resolved by an OIDC login, or writes
+// an mfa challenge when the user has MFA enabled, the same as a password login.
+func (h *UserAccountHandlers) completeOidcLogin(w http.ResponseWriter, r *http.Request, userId string, issuer string, detail string) {
+	logger.Get().Debug("Check if user has mfa enabled.")
+	mfaEnabled, err := h.mfaEnabled(userId)
+	if err != nil {
+		logger.Get().Error("Failed to select user mfa.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	if mfaEnabled {
+		if detail != "" {
+			detail += ", "
+		}
+
+		h.recordAudit(r, audit.UserLoginOidc, audit.Success, &userId, issuer, detail+"mfa required")
+		h.writeMfaChallenge(w, userId)
+		return
+	}
+
+	h.recordAudit(r, audit.UserLoginOidc, audit.Success, &userId, issuer, detail)
+	h.startSession(w, userId)
+}
+
+func (h *UserAccountHandlers) writeMfaChallenge(w http.ResponseWriter, userId string) {
+	logger.Get().Debug("Generate mfa challenge token.")
+	mfaToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		logger.Get().Error("Cannot generate mfa challenge token.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	now := time.Now().UTC()
+	err = h.UserAccountRepository.InsertMfaChallenge(MfaChallenge{
+		TokenHash:     auth.HashToken(mfaToken),
+		UserAccountId: userId,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(MfaChallengeDuration),
+	})
+	if err != nil {
+		logger.Get().Error("Failed to insert mfa challenge.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(&MfaChallengeDTO{
+		MfaRequired: true,
+		MfaToken:    mfaToken,
+		ExpiresIn:   int64(MfaChallengeDuration.Seconds()),
+	})
+	if err != nil {
+		logger.Get().Error("Failed to encode mfa challenge.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// startSession creates a new session for the user and writes its tokens to the response.
+func (h *UserAccountHandlers) startSession(w http.ResponseWriter, userId string) {
+	logger.Get().Debug("Generate refresh token.")
+	refreshToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		logger.Get().Error("Cannot generate refresh token.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Create new session.")
+	now := time.Now().UTC()
+	session := UserSession{
+		Id:            uuid.New().String(),
+		UserAccountId: userId,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(RefreshTokenDuration),
+	}
+
+	err = h.UserAccountRepository.InsertSession(session, RefreshToken{
+		TokenHash: auth.HashToken(refreshToken),
+		SessionId: session.Id,
+		CreatedAt: now,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		logger.Get().Error("Failed to insert session.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	h.writeTokenResponse(w, userId, session.Id, refreshToken)
+}
+
+func (h *UserAccountHandlers) writeTokenResponse(w http.ResponseWriter, userId string, sessionId string, refreshToken string) {
+	logger.Get().Debug("Sign access token.")
+	accessToken, err := SignAccessToken(h.KeySet, userId, sessionId)
+	if err != nil {
+		logger.Get().Error("Cannot sign token.", zap.Error(err))
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+
+	logger.Get().Debug("Construct response body.")
+	response := &LoginResponseDTO{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(AccessTokenDuration.Seconds()),
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		logger.Get().Error("Failed to encode tokens.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// writePasswordStrengthError writes the error response for a password that fails the
+// strength rules. It returns true when the password is acceptable.
+func writePasswordStrengthError(w http.ResponseWriter, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case ErrPasswordTooShort:
+		myhttp.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Password must be at least %d characters.", MinPasswordLength))
+	case ErrPasswordTooLong:
+		myhttp.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Password must be at most %d bytes.", MaxPasswordLength))
+	case ErrPasswordTooSimple:
+		myhttp.WriteError(w, http.StatusBadRequest, fmt.Sprintf("Password must use at least %d of lowercase, uppercase, digits and symbols.", MinPasswordCharacterClasses))
+	case ErrPasswordContainsUsername:
+		myhttp.WriteError(w, http.StatusBadRequest, "Password must not contain the username.")
+	case ErrPasswordCommon:
+		myhttp.WriteError(w, http.StatusBadRequest, "Password is too common.")
+	default:
+		myhttp.WriteError(w, http.StatusBadRequest, "Password is invalid.")
+	}
+
+	logger.Get().Warn("Password failed strength rules.", zap.Error(err))
+	return false
+}
+
+func writeProfileResponse(w http.ResponseWriter, userAccount *UserAccount) {
+	logger.Get().Debug("Construct response body.")
+	response := &UserProfileDTO{
+		Id:           userAccount.Id,
+		Username:     userAccount.Username,
+		DisplayName:  userAccount.DisplayName,
+		Email:        userAccount.Email,
+		Timezone:     userAccount.Timezone,
+		FavoriteTeam: userAccount.FavoriteTeam,
+		CreatedAt:    userAccount.CreatedAt,
+		UpdatedAt:    userAccount.UpdatedAt,
+	}
+
+	logger.Get().Debug("Encode response JSON and write to response.")
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		logger.Get().Error("Failed to encode profile.")
+		myhttp.WriteError(w, http.StatusInternalServerError, "Internal Server Error.")
+		return
+	}
+}
+
+// withAuthorizerContext returns a copy of the request carrying the authorized caller.
+func withAuthorizerContext(r *http.Request, ctx auth.AuthorizerContext) (*http.Request, error) {
+	logger.Get().Debug("Set authorizer context on request context.")
+	return r.WithContext(auth.WithAuthorizer(r.Context(), ctx)), nil
 }
@@ -2,9 +2,13 @@ package useracc
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ccthomas/gridiron/pkg/logger"
+	"github.com/ccthomas/gridiron/pkg/throttle"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -39,7 +43,7 @@ func (r *UserAccountRepositoryImpl) InsertUserAccount(userAccount UserAccount) e
 
 func (r *UserAccountRepositoryImpl) SelectByUsername(username string) (*UserAccount, error) {
 	logger.Get().Debug("Select user account by username.")
-	stmt, err := r.DB.Prepare("SELECT id, username, password_hash FROM user_account.user_account WHERE username = $1")
+	stmt, err := r.DB.Prepare("SELECT id, username, password_hash, display_name, email, timezone, favorite_team, created_at, updated_at FROM user_account.user_account WHERE username = $1")
 	if err != nil {
 		return nil, err
 	}
@@ -49,8 +53,7 @@ func (r *UserAccountRepositoryImpl) SelectByUsername(username string) (*UserAcco
 	row := stmt.QueryRow(username)
 
 	logger.Get().Debug("Scan the data into a user account struct.")
-	var userAccount UserAccount
-	err = row.Scan(&userAccount.Id, &userAccount.Username, &userAccount.PasswordHash)
+	userAccount, err := scanUserAccount(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			logger.Get().Debug("User account not found.")
@@ -60,5 +63,739 @@ func (r *UserAccountRepositoryImpl) SelectByUsername(username string) (*UserAcco
 	}
 
 	logger.Get().Debug("Found user account.", zap.String("Username", userAccount.Username))
+	return userAccount, nil
+}
+
+func (r *UserAccountRepositoryImpl) SelectById(id string) (*UserAccount, error) {
+	logger.Get().Debug("Select user account by id.")
+	row := r.DB.QueryRow("SELECT id, username, password_hash, display_name, email, timezone, favorite_team, created_at, updated_at FROM user_account.user_account WHERE id = $1", id)
+
+	logger.Get().Debug("Scan the data into a user account struct.")
+	userAccount, err := scanUserAccount(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("User account not found.")
+			return nil, fmt.Errorf("user account not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found user account.", zap.String("Username", userAccount.Username))
+	return userAccount, nil
+}
+
+func (r *UserAccountRepositoryImpl) UpdatePasswordHash(id string, passwordHash string) error {
+	logger.Get().Debug("Update password hash.")
+	_, err := r.DB.Exec("UPDATE user_account.user_account SET password_hash = $2 WHERE id = $1", id, passwordHash)
+	if err != nil {
+		logger.Get().Warn("Failed to update password hash.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully updated password hash.")
+	return nil
+}
+
+// UpdateProfile saves the profile fields of the user account.
+func (r *UserAccountRepositoryImpl) UpdateProfile(userAccount UserAccount) error {
+	logger.Get().Debug("Update user profile.")
+	_, err := r.DB.Exec(
+		"UPDATE user_account.user_account SET display_name = $2, email = $3, timezone = $4, favorite_team = $5, updated_at = $6 WHERE id = $1",
+		userAccount.Id, userAccount.DisplayName, userAccount.Email, userAccount.Timezone, userAccount.FavoriteTeam, userAccount.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "user_account_email_key" {
+			return ErrEmailTaken
+		}
+
+		logger.Get().Warn("Failed to update user profile.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully updated user profile.")
+	return nil
+}
+
+// DeleteUserAccount deletes the user account along with its tenant access. Sessions,
+// reset tokens, invitations and api keys are removed by cascade.
+func (r *UserAccountRepositoryImpl) DeleteUserAccount(id string) error {
+	logger.Get().Debug("Delete user account.", zap.String("Id", id))
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM tenant.tenant_user_access WHERE user_account_id = $1", id)
+	if err != nil {
+		logger.Get().Warn("Failed to delete tenant user access.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	result, err := tx.Exec("DELETE FROM user_account.user_account WHERE id = $1", id)
+	if err != nil {
+		logger.Get().Warn("Failed to delete user account.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if count == 0 {
+		tx.Rollback()
+		return fmt.Errorf("user account not found")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully deleted user account.")
+	return nil
+}
+
+// InsertSession inserts a new user session along with the first refresh token issued for it.
+func (r *UserAccountRepositoryImpl) InsertSession(session UserSession, refreshToken RefreshToken) error {
+	logger.Get().Debug("Insert user session.")
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO user_account.user_session (id, user_account_id, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		session.Id, session.UserAccountId, session.CreatedAt, session.ExpiresAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert user session.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO user_account.refresh_token (token_hash, session_id, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		refreshToken.TokenHash, refreshToken.SessionId, refreshToken.CreatedAt, refreshToken.ExpiresAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert refresh token.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted user session.")
+	return nil
+}
+
+func (r *UserAccountRepositoryImpl) SelectSession(id string) (*UserSession, error) {
+	logger.Get().Debug("Select user session by id.")
+	row := r.DB.QueryRow("SELECT id, user_account_id, created_at, expires_at, revoked_at FROM user_account.user_session WHERE id = $1", id)
+
+	logger.Get().Debug("Scan the data into a user session struct.")
+	var session UserSession
+	err := row.Scan(&session.Id, &session.UserAccountId, &session.CreatedAt, &session.ExpiresAt, &session.RevokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("User session not found.")
+			return nil, fmt.Errorf("user session not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found user session.", zap.String("Id", session.Id))
+	return &session, nil
+}
+
+// RevokeSession marks a session as revoked. Revoking an already revoked session is a no-op.
+func (r *UserAccountRepositoryImpl) RevokeSession(id string) error {
+	logger.Get().Debug("Revoke user session.")
+	_, err := r.DB.Exec("UPDATE user_account.user_session SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		logger.Get().Warn("Failed to revoke user session.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully revoked user session.")
+	return nil
+}
+
+// RevokeSessionsByUser revokes every active session of the user except the one given.
+// Pass an empty exceptSessionId to revoke all sessions.
+func (r *UserAccountRepositoryImpl) RevokeSessionsByUser(userId string, exceptSessionId string) error {
+	logger.Get().Debug("Revoke user sessions.")
+	_, err := r.DB.Exec("UPDATE user_account.user_session SET revoked_at = NOW() WHERE user_account_id = $1 AND id <> $2 AND revoked_at IS NULL", userId, exceptSessionId)
+	if err != nil {
+		logger.Get().Warn("Failed to revoke user sessions.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully revoked user sessions.")
+	return nil
+}
+
+func (r *UserAccountRepositoryImpl) SelectRefreshToken(tokenHash string) (*RefreshToken, error) {
+	logger.Get().Debug("Select refresh token by hash.")
+	row := r.DB.QueryRow("SELECT token_hash, session_id, created_at, expires_at, used_at FROM user_account.refresh_token WHERE token_hash = $1", tokenHash)
+
+	logger.Get().Debug("Scan the data into a refresh token struct.")
+	var refreshToken RefreshToken
+	err := row.Scan(&refreshToken.TokenHash, &refreshToken.SessionId, &refreshToken.CreatedAt, &refreshToken.ExpiresAt, &refreshToken.UsedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Refresh token not found.")
+			return nil, fmt.Errorf("refresh token not found")
+		}
+		return nil, err
+	}
+
+	logger.Get().Debug("Found refresh token.", zap.String("SessionId", refreshToken.SessionId))
+	return &refreshToken, nil
+}
+
+// RotateRefreshToken marks the used refresh token as consumed and stores its replacement.
+// ErrRefreshTokenUsed is returned when the used token was already consumed by another request.
+func (r *UserAccountRepositoryImpl) RotateRefreshToken(usedTokenHash string, refreshToken RefreshToken) error {
+	logger.Get().Debug("Rotate refresh token.")
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return err
+	}
+
+	result, err := tx.Exec("UPDATE user_account.refresh_token SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL", usedTokenHash)
+	if err != nil {
+		logger.Get().Warn("Failed to mark refresh token as used.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if count == 0 {
+		logger.Get().Warn("Refresh token was already used.")
+		tx.Rollback()
+		return ErrRefreshTokenUsed
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO user_account.refresh_token (token_hash, session_id, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		refreshToken.TokenHash, refreshToken.SessionId, refreshToken.CreatedAt, refreshToken.ExpiresAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert refresh token.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully rotated refresh token.")
+	return nil
+}
+
+func (r *UserAccountRepositoryImpl) InsertPasswordResetToken(resetToken PasswordResetToken) error {
+	logger.Get().Debug("Insert password reset token.")
+	_, err := r.DB.Exec(
+		"INSERT INTO user_account.password_reset_token (token_hash, user_account_id, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		resetToken.TokenHash, resetToken.UserAccountId, resetToken.CreatedAt, resetToken.ExpiresAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert password reset token.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted password reset token.")
+	return nil
+}
+
+// SelectPasswordResetTokenUser returns the id of the user an unused, unexpired reset
+// token was issued to without consuming the token.
+func (r *UserAccountRepositoryImpl) SelectPasswordResetTokenUser(tokenHash string) (string, error) {
+	logger.Get().Debug("Select password reset token user.")
+	row := r.DB.QueryRow("SELECT user_account_id FROM user_account.password_reset_token WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()", tokenHash)
+
+	var userId string
+	err := row.Scan(&userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Password reset token not found or no longer valid.")
+			return "", fmt.Errorf("password reset token not found: %w", sql.ErrNoRows)
+		}
+		return "", err
+	}
+
+	return userId, nil
+}
+
+// ConsumePasswordResetToken marks an unused, unexpired reset token as used and
+// returns the id of the user it was issued to.
+func (r *UserAccountRepositoryImpl) ConsumePasswordResetToken(tokenHash string) (string, error) {
+	logger.Get().Debug("Consume password reset token.")
+	row := r.DB.QueryRow("UPDATE user_account.password_reset_token SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW() RETURNING user_account_id", tokenHash)
+
+	var userId string
+	err := row.Scan(&userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Password reset token not found or no longer valid.")
+			return "", fmt.Errorf("password reset token not found")
+		}
+		return "", err
+	}
+
+	logger.Get().Debug("Successfully consumed password reset token.")
+	return userId, nil
+}
+
+func (r *UserAccountRepositoryImpl) InsertOidcLoginState(state OidcLoginState) error {
+	logger.Get().Debug("Insert oidc login state.")
+	_, err := r.DB.Exec(
+		"INSERT INTO user_account.oidc_login_state (state_hash, nonce, code_verifier, user_account_id, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		state.StateHash, state.Nonce, state.CodeVerifier, state.UserAccountId, state.CreatedAt, state.ExpiresAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert oidc login state.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted oidc login state.")
+	return nil
+}
+
+// ConsumeOidcLoginState deletes and returns the login state, so each state can only be used once.
+func (r *UserAccountRepositoryImpl) ConsumeOidcLoginState(stateHash string) (*OidcLoginState, error) {
+	logger.Get().Debug("Consume oidc login state.")
+	row := r.DB.QueryRow("DELETE FROM user_account.oidc_login_state WHERE state_hash = $1 RETURNING state_hash, nonce, code_verifier, user_account_id, created_at, expires_at", stateHash)
+
+	var state OidcLoginState
+	err := row.Scan(&state.StateHash, &state.Nonce, &state.CodeVerifier, &state.UserAccountId, &state.CreatedAt, &state.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Oidc login state not found.")
+			return nil, fmt.Errorf("oidc login state not found")
+		}
+		return nil, err
+	}
+
+	if time.Now().After(state.ExpiresAt) {
+		logger.Get().Debug("Oidc login state expired.")
+		return nil, fmt.Errorf("oidc login state not found")
+	}
+
+	logger.Get().Debug("Successfully consumed oidc login state.")
+	return &state, nil
+}
+
+func (r *UserAccountRepositoryImpl) SelectExternalIdentity(issuer string, subject string) (*ExternalIdentity, error) {
+	logger.Get().Debug("Select external identity.")
+	row := r.DB.QueryRow("SELECT issuer, subject, user_account_id, email, created_at FROM user_account.external_identity WHERE issuer = $1 AND subject = $2", issuer, subject)
+
+	var identity ExternalIdentity
+	err := row.Scan(&identity.Issuer, &identity.Subject, &identity.UserAccountId, &identity.Email, &identity.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("External identity not found.")
+			return nil, fmt.Errorf("external identity not found: %w", sql.ErrNoRows)
+		}
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (r *UserAccountRepositoryImpl) InsertExternalIdentity(identity ExternalIdentity) error {
+	logger.Get().Debug("Insert external identity.")
+	_, err := r.DB.Exec(
+		"INSERT INTO user_account.external_identity (issuer, subject, user_account_id, email, created_at) VALUES ($1, $2, $3, $4, $5)",
+		identity.Issuer, identity.Subject, identity.UserAccountId, identity.Email, identity.CreatedAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert external identity.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted external identity.")
+	return nil
+}
+
+// InsertUserAccountWithIdentity creates a user account for a first time OIDC login together with its identity link.
+func (r *UserAccountRepositoryImpl) InsertUserAccountWithIdentity(userAccount UserAccount, identity ExternalIdentity) error {
+	logger.Get().Debug("Insert user account with external identity.")
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO user_account.user_account (id, username, password_hash, display_name) VALUES ($1, $2, $3, $4)",
+		userAccount.Id, userAccount.Username, userAccount.PasswordHash, userAccount.DisplayName,
+	)
+	if err != nil {
+		tx.Rollback()
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "user_account_username_key" {
+			return ErrUsernameTaken
+		}
+
+		logger.Get().Warn("Failed to insert user account.", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO user_account.external_identity (issuer, subject, user_account_id, email, created_at) VALUES ($1, $2, $3, $4, $5)",
+		identity.Issuer, identity.Subject, identity.UserAccountId, identity.Email, identity.CreatedAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert external identity.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted user account with external identity.")
+	return nil
+}
+
+func (r *UserAccountRepositoryImpl) SelectMfa(userId string) (*UserMfa, error) {
+	logger.Get().Debug("Select user mfa.")
+	row := r.DB.QueryRow("SELECT user_account_id, totp_secret, last_used_step, created_at, enabled_at FROM user_account.user_mfa WHERE user_account_id = $1", userId)
+
+	var mfa UserMfa
+	err := row.Scan(&mfa.UserAccountId, &mfa.TotpSecret, &mfa.LastUsedStep, &mfa.CreatedAt, &mfa.EnabledAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("User mfa not found.")
+			return nil, fmt.Errorf("user mfa not found: %w", sql.ErrNoRows)
+		}
+		return nil, err
+	}
+
+	return &mfa, nil
+}
+
+// UpsertPendingMfa starts, or restarts, a TOTP enrollment. An enabled enrollment is never replaced.
+func (r *UserAccountRepositoryImpl) UpsertPendingMfa(mfa UserMfa) error {
+	logger.Get().Debug("Upsert pending user mfa.")
+	result, err := r.DB.Exec(
+		"INSERT INTO user_account.user_mfa (user_account_id, totp_secret, last_used_step, created_at) VALUES ($1, $2, 0, $3) ON CONFLICT (user_account_id) DO UPDATE SET totp_secret = EXCLUDED.totp_secret, last_used_step = 0, created_at = EXCLUDED.created_at WHERE user_mfa.enabled_at IS NULL",
+		mfa.UserAccountId, mfa.TotpSecret, mfa.CreatedAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to upsert pending user mfa.")
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return ErrMfaAlreadyEnabled
+	}
+
+	logger.Get().Debug("Successfully upserted pending user mfa.")
+	return nil
+}
+
+// EnableMfa enables the pending enrollment and replaces the user's recovery codes.
+func (r *UserAccountRepositoryImpl) EnableMfa(userId string, step int64, recoveryCodeHashes []string) error {
+	logger.Get().Debug("Enable user mfa.")
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return err
+	}
+
+	result, err := tx.Exec("UPDATE user_account.user_mfa SET enabled_at = NOW(), last_used_step = $2 WHERE user_account_id = $1 AND enabled_at IS NULL", userId, step)
+	if err != nil {
+		logger.Get().Warn("Failed to enable user mfa.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if count == 0 {
+		tx.Rollback()
+		return ErrMfaAlreadyEnabled
+	}
+
+	_, err = tx.Exec("DELETE FROM user_account.mfa_recovery_code WHERE user_account_id = $1", userId)
+	if err != nil {
+		logger.Get().Warn("Failed to delete recovery codes.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.Exec("INSERT INTO user_account.mfa_recovery_code (code_hash, user_account_id) VALUES ($1, $2)", codeHash, userId)
+		if err != nil {
+			logger.Get().Warn("Failed to insert recovery code.", zap.Error(err))
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully enabled user mfa.")
+	return nil
+}
+
+// UpdateMfaLastUsedStep records the time step of an accepted code. It returns false
+// when the step was already used, so each code is only accepted once.
+func (r *UserAccountRepositoryImpl) UpdateMfaLastUsedStep(userId string, step int64) (bool, error) {
+	logger.Get().Debug("Update user mfa last used step.")
+	result, err := r.DB.Exec("UPDATE user_account.user_mfa SET last_used_step = $2 WHERE user_account_id = $1 AND last_used_step < $2", userId, step)
+	if err != nil {
+		logger.Get().Warn("Failed to update user mfa last used step.")
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ConsumeRecoveryCode marks the recovery code used. It returns false when the code is unknown or already used.
+func (r *UserAccountRepositoryImpl) ConsumeRecoveryCode(userId string, codeHash string) (bool, error) {
+	logger.Get().Debug("Consume recovery code.")
+	result, err := r.DB.Exec("UPDATE user_account.mfa_recovery_code SET used_at = NOW() WHERE user_account_id = $1 AND code_hash = $2 AND used_at IS NULL", userId, codeHash)
+	if err != nil {
+		logger.Get().Warn("Failed to consume recovery code.")
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *UserAccountRepositoryImpl) DeleteMfa(userId string) error {
+	logger.Get().Debug("Delete user mfa.")
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM user_account.mfa_recovery_code WHERE user_account_id = $1", userId)
+	if err != nil {
+		logger.Get().Warn("Failed to delete recovery codes.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM user_account.user_mfa WHERE user_account_id = $1", userId)
+	if err != nil {
+		logger.Get().Warn("Failed to delete user mfa.", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Get().Error("Failed to commit transaction:", zap.Error(err))
+		return err
+	}
+
+	logger.Get().Debug("Successfully deleted user mfa.")
+	return nil
+}
+
+func (r *UserAccountRepositoryImpl) InsertMfaChallenge(challenge MfaChallenge) error {
+	logger.Get().Debug("Insert mfa challenge.")
+	_, err := r.DB.Exec(
+		"INSERT INTO user_account.mfa_challenge (token_hash, user_account_id, attempts, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)",
+		challenge.TokenHash, challenge.UserAccountId, challenge.Attempts, challenge.CreatedAt, challenge.ExpiresAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert mfa challenge.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted mfa challenge.")
+	return nil
+}
+
+// ClaimMfaChallengeAttempt counts an attempt against an unexpired challenge with attempts left
+// and returns it. The claim is one statement, so concurrent attempts cannot exceed maxAttempts.
+func (r *UserAccountRepositoryImpl) ClaimMfaChallengeAttempt(tokenHash string, maxAttempts int) (*MfaChallenge, error) {
+	logger.Get().Debug("Claim mfa challenge attempt.")
+	row := r.DB.QueryRow("UPDATE user_account.mfa_challenge SET attempts = attempts + 1 WHERE token_hash = $1 AND attempts < $2 AND expires_at > NOW() RETURNING token_hash, user_account_id, attempts, created_at, expires_at", tokenHash, maxAttempts)
+
+	var challenge MfaChallenge
+	err := row.Scan(&challenge.TokenHash, &challenge.UserAccountId, &challenge.Attempts, &challenge.CreatedAt, &challenge.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Get().Debug("Mfa challenge not found, expired or exhausted.")
+			return nil, fmt.Errorf("mfa challenge not found: %w", sql.ErrNoRows)
+		}
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// DeleteMfaChallenge redeems the challenge. Only one caller can delete it; the others get an
+// error wrapping sql.ErrNoRows.
+func (r *UserAccountRepositoryImpl) DeleteMfaChallenge(tokenHash string) error {
+	logger.Get().Debug("Delete mfa challenge.")
+	var deleted string
+	err := r.DB.QueryRow("DELETE FROM user_account.mfa_challenge WHERE token_hash = $1 RETURNING token_hash", tokenHash).Scan(&deleted)
+	if err == sql.ErrNoRows {
+		logger.Get().Debug("Mfa challenge already redeemed.")
+		return fmt.Errorf("mfa challenge not found: %w", sql.ErrNoRows)
+	}
+
+	return err
+}
+
+func (r *UserAccountRepositoryImpl) InsertLockEvent(event AccountLockEvent) error {
+	logger.Get().Debug("Insert account lock event.")
+	_, err := r.DB.Exec(
+		"INSERT INTO user_account.account_lock_event (id, username, event, actor_user_account_id, created_at) VALUES ($1, $2, $3, $4, $5)",
+		event.Id, event.Username, event.Event, event.ActorUserAccountId, event.CreatedAt,
+	)
+	if err != nil {
+		logger.Get().Warn("Failed to insert account lock event.")
+		return err
+	}
+
+	logger.Get().Debug("Successfully inserted account lock event.")
+	return nil
+}
+
+func (r *UserAccountRepositoryImpl) SelectLockEventsByUsername(username string) ([]AccountLockEvent, error) {
+	logger.Get().Debug("Select account lock events by username.")
+	rows, err := r.DB.Query("SELECT id, username, event, actor_user_account_id, created_at FROM user_account.account_lock_event WHERE username = $1 ORDER BY created_at DESC", username)
+
+	if err != nil {
+		logger.Get().Warn("Failed to select account lock events.")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	logger.Get().Debug("Start scanning rows.")
+	var events []AccountLockEvent
+	for rows.Next() {
+		var event AccountLockEvent
+		logger.Get().Debug("Scan next row.")
+		if err := rows.Scan(&event.Id, &event.Username, &event.Event, &event.ActorUserAccountId, &event.CreatedAt); err != nil {
+			logger.Get().Warn("Failed to scan row.")
+			return nil, err
+		}
+
+		logger.Get().Debug("Add event to events array.")
+		events = append(events, event)
+	}
+
+	logger.Get().Debug("Return events.")
+	return events, nil
+}
+
+// SelectAttempt implements throttle.Store. A missing key returns nil state.
+func (r *UserAccountRepositoryImpl) SelectAttempt(key string) (*throttle.State, error) {
+	row := r.DB.QueryRow("SELECT failures, last_failure_at, locked_until FROM user_account.login_attempt WHERE attempt_key = $1", key)
+
+	var state throttle.State
+	err := row.Scan(&state.Failures, &state.LastFailureAt, &state.LockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// UpsertAttempt implements throttle.Store.
+func (r *UserAccountRepositoryImpl) UpsertAttempt(key string, state throttle.State) error {
+	_, err := r.DB.Exec(
+		"INSERT INTO user_account.login_attempt (attempt_key, failures, last_failure_at, locked_until) VALUES ($1, $2, $3, $4) ON CONFLICT (attempt_key) DO UPDATE SET failures = EXCLUDED.failures, last_failure_at = EXCLUDED.last_failure_at, locked_until = EXCLUDED.locked_until",
+		key, state.Failures, state.LastFailureAt, state.LockedUntil,
+	)
+	return err
+}
+
+// DeleteAttempt implements throttle.Store.
+func (r *UserAccountRepositoryImpl) DeleteAttempt(key string) error {
+	_, err := r.DB.Exec("DELETE FROM user_account.login_attempt WHERE attempt_key = $1", key)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUserAccount(row rowScanner) (*UserAccount, error) {
+	var userAccount UserAccount
+	err := row.Scan(
+		&userAccount.Id,
+		&userAccount.Username,
+		&userAccount.PasswordHash,
+		&userAccount.DisplayName,
+		&userAccount.Email,
+		&userAccount.Timezone,
+		&userAccount.FavoriteTeam,
+		&userAccount.CreatedAt,
+		&userAccount.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &userAccount, nil
 }
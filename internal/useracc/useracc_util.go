@@ -1,13 +1,333 @@
 package useracc
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/oidc"
+	"github.com/ccthomas/gridiron/pkg/signing"
+	"github.com/ccthomas/gridiron/pkg/throttle"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultUserLoginPolicy throttles login failures for a single username.
+var DefaultUserLoginPolicy = throttle.Policy{
+	FreeAttempts:     3,
+	BaseDelay:        time.Second,
+	MaxDelay:         time.Minute,
+	LockoutThreshold: 10,
+	LockoutDuration:  15 * time.Minute,
+}
+
+// DefaultIpLoginPolicy throttles login failures from a single ip address. It is
+// looser than the username policy because many users can share an address.
+var DefaultIpLoginPolicy = throttle.Policy{
+	FreeAttempts:     20,
+	BaseDelay:        time.Second,
+	MaxDelay:         time.Minute,
+	LockoutThreshold: 100,
+	LockoutDuration:  15 * time.Minute,
+}
+
+// DefaultPasswordHashPolicy hashes with argon2id using the OWASP recommended minimum parameters.
+var DefaultPasswordHashPolicy = PasswordHashPolicy{
+	Algorithm:        Argon2id,
+	BcryptCost:       12,
+	Argon2Time:       2,
+	Argon2MemoryKiB:  19 * 1024,
+	Argon2Threads:    1,
+	Argon2KeyLength:  32,
+	Argon2SaltLength: 16,
+}
+
+// commonPasswords lists well known passwords that meet the length and character class
+// rules. Compared ignoring case.
+var commonPasswords = map[string]bool{
+	"password1234":  true,
+	"password123!":  true,
+	"passw0rd1234":  true,
+	"p@ssw0rd1234":  true,
+	"qwerty123456":  true,
+	"qwertyuiop123": true,
+	"welcome12345":  true,
+	"letmein12345":  true,
+	"iloveyou1234":  true,
+	"football1234":  true,
+	"admin1234567":  true,
+	"changeme1234":  true,
+}
+
+// PasswordHashPolicyFromEnv reads PASSWORD_HASH_ALGORITHM, PASSWORD_BCRYPT_COST,
+// PASSWORD_ARGON2_TIME, PASSWORD_ARGON2_MEMORY_KIB and PASSWORD_ARGON2_THREADS,
+// keeping the defaults for anything unset or invalid.
+func PasswordHashPolicyFromEnv(defaults PasswordHashPolicy) PasswordHashPolicy {
+	policy := defaults
+
+	switch algorithm := PasswordHashAlgorithm(os.Getenv("PASSWORD_HASH_ALGORITHM")); algorithm {
+	case Argon2id, Bcrypt:
+		policy.Algorithm = algorithm
+	}
+
+	if cost, err := strconv.Atoi(os.Getenv("PASSWORD_BCRYPT_COST")); err == nil && cost >= bcrypt.MinCost && cost <= bcrypt.MaxCost {
+		policy.BcryptCost = cost
+	}
+
+	if t, err := strconv.ParseUint(os.Getenv("PASSWORD_ARGON2_TIME"), 10, 32); err == nil && t > 0 {
+		policy.Argon2Time = uint32(t)
+	}
+
+	if m, err := strconv.ParseUint(os.Getenv("PASSWORD_ARGON2_MEMORY_KIB"), 10, 32); err == nil && m > 0 {
+		policy.Argon2MemoryKiB = uint32(m)
+	}
+
+	if p, err := strconv.ParseUint(os.Getenv("PASSWORD_ARGON2_THREADS"), 10, 8); err == nil && p > 0 {
+		policy.Argon2Threads = uint8(p)
+	}
+
+	return policy
 }
 
-func CheckPasswordHash(password, hash string) bool {
+// Hash hashes the password with the policy's algorithm. Argon2id hashes use the PHC
+// string format, $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>.
+func (p PasswordHashPolicy) Hash(password string) (string, error) {
+	if p.Algorithm == Bcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+		return string(bytes), err
+	}
+
+	salt := make([]byte, p.Argon2SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Argon2Time, p.Argon2MemoryKiB, p.Argon2Threads, p.Argon2KeyLength)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Argon2MemoryKiB, p.Argon2Time, p.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// HasPassword reports whether the account has a password. Accounts created by an OIDC
+// login have none until one is set.
+func HasPassword(userAccount *UserAccount) bool {
+	return userAccount.PasswordHash != ""
+}
+
+// Verify reports whether the password matches the hash, whichever supported algorithm
+// made it, and whether a matching hash should be replaced because it was not made
+// under this policy.
+func (p PasswordHashPolicy) Verify(password string, hash string) (bool, bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		var version int
+		var memory, iterations uint32
+		var threads uint8
+		parts := strings.Split(hash, "$")
+		if len(parts) != 6 {
+			return false, false
+		}
+
+		_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+		if err != nil || version != argon2.Version {
+			return false, false
+		}
+
+		_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads)
+		if err != nil {
+			return false, false
+		}
+
+		salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+		if err != nil {
+			return false, false
+		}
+
+		key, err := base64.RawStdEncoding.DecodeString(parts[5])
+		if err != nil {
+			return false, false
+		}
+
+		actual := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return false, false
+		}
+
+		needsRehash := p.Algorithm != Argon2id ||
+			memory != p.Argon2MemoryKiB ||
+			iterations != p.Argon2Time ||
+			threads != p.Argon2Threads ||
+			uint32(len(key)) != p.Argon2KeyLength ||
+			uint32(len(salt)) != p.Argon2SaltLength
+		return true, needsRehash
+	}
+
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	if err != nil {
+		return false, false
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, p.Algorithm != Bcrypt || err != nil || cost != p.BcryptCost
+}
+
+// ValidatePasswordStrength checks a new password against the strength rules. The
+// username check is skipped when the username is empty.
+func ValidatePasswordStrength(password string, username string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+
+	if len(password) > MaxPasswordLength {
+		return ErrPasswordTooLong
+	}
+
+	var lower, upper, digit, other bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			classes++
+		}
+	}
+
+	if classes < MinPasswordCharacterClasses {
+		return ErrPasswordTooSimple
+	}
+
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return ErrPasswordContainsUsername
+	}
+
+	if commonPasswords[strings.ToLower(password)] {
+		return ErrPasswordCommon
+	}
+
+	return nil
+}
+
+// SignAccessToken signs a short lived access token bound to the given session.
+func SignAccessToken(keySet *signing.KeySet, userId string, sessionId string) (string, error) {
+	return keySet.Sign(jwt.MapClaims{
+		"sub": userId,
+		"sid": sessionId,
+		"jti": uuid.New().String(),
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(AccessTokenDuration).Unix(),
+	})
+}
+
+// ApplyProfileUpdate validates the update and applies it to the user account. Omitted
+// fields are left unchanged and blank fields are cleared.
+func ApplyProfileUpdate(userAccount *UserAccount, dto UpdateProfileDTO) error {
+	displayName, err := normalizeProfileField(dto.DisplayName, userAccount.DisplayName)
+	if err != nil {
+		return err
+	}
+
+	email, err := normalizeProfileField(dto.Email, userAccount.Email)
+	if err != nil {
+		return err
+	}
+
+	if email != nil && email != userAccount.Email {
+		address, err := mail.ParseAddress(*email)
+		if err != nil || address.Address != *email {
+			return ErrInvalidEmail
+		}
+	}
+
+	timezone, err := normalizeProfileField(dto.Timezone, userAccount.Timezone)
+	if err != nil {
+		return err
+	}
+
+	if timezone != nil && timezone != userAccount.Timezone {
+		if _, err := time.LoadLocation(*timezone); err != nil || *timezone == "Local" {
+			return ErrInvalidTimezone
+		}
+	}
+
+	favoriteTeam, err := normalizeProfileField(dto.FavoriteTeam, userAccount.FavoriteTeam)
+	if err != nil {
+		return err
+	}
+
+	userAccount.DisplayName = displayName
+	userAccount.Email = email
+	userAccount.Timezone = timezone
+	userAccount.FavoriteTeam = favoriteTeam
+	return nil
+}
+
+func normalizeProfileField(value *string, current *string) (*string, error) {
+	if value == nil {
+		return current, nil
+	}
+
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	if len(trimmed) > MaxProfileFieldLength {
+		return nil, ErrFieldTooLong
+	}
+
+	return &trimmed, nil
+}
+
+// OidcUsername picks the username for an account created by a first time OIDC login.
+func OidcUsername(claims *oidc.IdTokenClaims) string {
+	for _, candidate := range []string{claims.PreferredUsername, claims.Email} {
+		candidate = strings.TrimSpace(candidate)
+		if candidate != "" && len(candidate) <= MaxProfileFieldLength-9 {
+			return candidate
+		}
+	}
+
+	return fmt.Sprintf("oidc-%s", auth.HashToken(claims.Issuer + "|" + claims.Subject)[:16])
+}
+
+// GenerateRecoveryCode returns a single use MFA recovery code formatted as two groups of five characters.
+func GenerateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// HashRecoveryCode hashes the recovery code, ignoring case, spaces and dashes.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return auth.HashToken(normalized)
 }
@@ -6,12 +6,18 @@ import (
 	"os"
 
 	"github.com/ccthomas/gridiron/api"
+	"github.com/ccthomas/gridiron/internal/audit"
 	"github.com/ccthomas/gridiron/internal/team"
 	"github.com/ccthomas/gridiron/internal/tenant"
 	"github.com/ccthomas/gridiron/internal/useracc"
 	"github.com/ccthomas/gridiron/pkg/database"
+	"github.com/ccthomas/gridiron/pkg/league"
 	gridironLogger "github.com/ccthomas/gridiron/pkg/logger"
+	"github.com/ccthomas/gridiron/pkg/notify"
+	"github.com/ccthomas/gridiron/pkg/oidc"
 	"github.com/ccthomas/gridiron/pkg/rabbitmq"
+	"github.com/ccthomas/gridiron/pkg/signing"
+	"github.com/ccthomas/gridiron/pkg/throttle"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
@@ -22,6 +28,12 @@ func main() {
 
 	logger.Info("Starting Gridiron...")
 
+	logger.Debug("Load league templates.")
+	err := league.LoadFromEnv()
+	if err != nil {
+		logger.Fatal("Failed to load league templates.", zap.Error(err))
+	}
+
 	logger.Debug("Connect to rabbit mq.")
 	amqpConnection := rabbitmq.ConnectRabbitMQ()
 	defer amqpConnection.Close()
@@ -52,8 +64,45 @@ func main() {
 		DB: db,
 	}
 
+	auditRepo := &audit.AuditRepositoryImpl{
+		DB: db,
+	}
+
+	logger.Debug("Construct notifier.")
+	notifier := notify.NewNotifier()
+
+	logger.Debug("Construct login trackers.")
+	var loginAttemptStore throttle.Store
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "postgres" {
+		loginAttemptStore = userRepo
+	}
+
+	userLoginTracker := throttle.NewTracker(throttle.PolicyFromEnv("LOGIN_USER_", useracc.DefaultUserLoginPolicy), loginAttemptStore)
+	ipLoginTracker := throttle.NewTracker(throttle.PolicyFromEnv("LOGIN_IP_", useracc.DefaultIpLoginPolicy), loginAttemptStore)
+
+	logger.Debug("Load password hash policy.")
+	passwordHashPolicy := useracc.PasswordHashPolicyFromEnv(useracc.DefaultPasswordHashPolicy)
+
+	logger.Debug("Load tenant quota policy.")
+	quotaPolicy := tenant.QuotaPolicyFromEnv(tenant.DefaultQuotaPolicy)
+
+	logger.Debug("Load tenant retention policy.")
+	retentionPolicy := tenant.RetentionPolicyFromEnv(tenant.DefaultRetentionPolicy)
+
+	logger.Debug("Load token signing keys.")
+	keySet, err := signing.LoadKeySetFromEnv()
+	if err != nil {
+		logger.Fatal("Failed to load token signing keys.", zap.Error(err))
+	}
+
+	var oidcProvider *oidc.Provider
+	if oidcConfig, ok := oidc.ConfigFromEnv(); ok {
+		logger.Debug("Construct oidc provider.")
+		oidcProvider = oidc.NewProvider(oidcConfig)
+	}
+
 	logger.Debug("Construct handlers.")
-	handler := api.NewHandlers(db, rmq, teamRepo, tenantRepo, userRepo)
+	handler := api.NewHandlers(db, rmq, teamRepo, tenantRepo, userRepo, notifier, userLoginTracker, ipLoginTracker, keySet, oidcProvider, auditRepo, passwordHashPolicy, quotaPolicy, retentionPolicy)
 
 	logger.Debug("Construct router.")
 	r := mux.NewRouter()
@@ -61,6 +110,9 @@ func main() {
 	logger.Debug("Route paths to handlers.")
 	handler.RouteApis(r, rmq)
 
+	logger.Debug("Start tenant purge job.")
+	go handler.TenantHandlers.RunPurgeJob()
+
 	logger.Debug("Handle router with http.")
 	http.Handle("/", r)
 
@@ -3,10 +3,50 @@ package auth
 type AccessLevel string
 
 const (
-	Owner AccessLevel = "OWNER"
+	Owner  AccessLevel = "OWNER"
+	Admin  AccessLevel = "ADMIN"
+	Editor AccessLevel = "EDITOR"
+	Scorer AccessLevel = "SCORER"
+	Viewer AccessLevel = "VIEWER"
 )
 
+type Permission string
+
+const (
+	TenantRead   Permission = "tenant:read"
+	TenantManage Permission = "tenant:manage"
+	TenantDelete Permission = "tenant:delete"
+	TeamRead     Permission = "team:read"
+	TeamWrite    Permission = "team:write"
+	ScoreWrite   Permission = "score:write"
+	AuditRead    Permission = "audit:read"
+
+	TenantTransfer Permission = "tenant:transfer"
+)
+
+// permissionMatrix lists the permissions granted to each access level within a tenant.
+var permissionMatrix = map[AccessLevel][]Permission{
+	Owner:  {TenantRead, TenantManage, TenantDelete, TenantTransfer, TeamRead, TeamWrite, ScoreWrite, AuditRead},
+	Admin:  {TenantRead, TenantManage, TeamRead, TeamWrite, ScoreWrite},
+	Editor: {TenantRead, TeamRead, TeamWrite, ScoreWrite},
+	Scorer: {TenantRead, TeamRead, ScoreWrite},
+	Viewer: {TenantRead, TeamRead},
+}
+
+// accessRank orders the access levels within a tenant, highest first.
+var accessRank = map[AccessLevel]int{
+	Owner:  5,
+	Admin:  4,
+	Editor: 3,
+	Scorer: 2,
+	Viewer: 1,
+}
+
 type AuthorizerContext struct {
 	UserId       string                 `json:"user_id"`
+	SessionId    string                 `json:"session_id,omitempty"`
+	TokenId      string                 `json:"token_id,omitempty"`
+	ApiKeyId     string                 `json:"api_key_id,omitempty"`
+	Scopes       []Permission           `json:"scopes,omitempty"`
 	TenantAccess map[string]AccessLevel `json:"tenant_access"`
 }
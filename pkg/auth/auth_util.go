@@ -1,18 +1,112 @@
 package auth
 
 import (
-	"encoding/json"
-	"net/http"
-
-	"github.com/ccthomas/gridiron/pkg/logger"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strings"
 )
 
-func GetAuthorizerContext(r *http.Request) (AuthorizerContext, error) {
-	logger.Get().Debug("Get Authorizer Context.")
+type authorizerKey struct{}
+
+// WithAuthorizer returns a copy of ctx carrying the authorized caller.
+func WithAuthorizer(ctx context.Context, authorizer AuthorizerContext) context.Context {
+	return context.WithValue(ctx, authorizerKey{}, authorizer)
+}
+
+// FromContext returns the caller authorized by the token or api key authorizer.
+func FromContext(ctx context.Context) (AuthorizerContext, bool) {
+	authorizer, ok := ctx.Value(authorizerKey{}).(AuthorizerContext)
+	return authorizer, ok
+}
+
+type tenantIdKey struct{}
+
+// WithTenantId returns a copy of ctx scoped to the tenant.
+func WithTenantId(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, tenantIdKey{}, tenantId)
+}
+
+// TenantIdFromContext returns the tenant the request was scoped to by the tenant scope middleware.
+func TenantIdFromContext(ctx context.Context) (string, bool) {
+	tenantId, ok := ctx.Value(tenantIdKey{}).(string)
+	return tenantId, ok && tenantId != ""
+}
+
+// IsValid reports whether the access level is one of the known roles.
+func (a AccessLevel) IsValid() bool {
+	_, ok := permissionMatrix[a]
+	return ok
+}
+
+// HasPermission reports whether the access level grants the permission.
+func (a AccessLevel) HasPermission(permission Permission) bool {
+	return containsPermission(permissionMatrix[a], permission)
+}
+
+// Outranks reports whether the access level is strictly above the other.
+func (a AccessLevel) Outranks(other AccessLevel) bool {
+	return accessRank[a] > accessRank[other]
+}
+
+// IsValid reports whether the permission is one of the known permissions.
+func (p Permission) IsValid() bool {
+	return Owner.HasPermission(p)
+}
+
+// HasPermission reports whether the caller holds the permission within the tenant.
+// Callers authenticated with scoped credentials, such as api keys, are further
+// limited to their scopes.
+func (c AuthorizerContext) HasPermission(tenantId string, permission Permission) bool {
+	accessLevel, ok := c.TenantAccess[tenantId]
+	if !ok {
+		return false
+	}
+
+	if c.Scopes != nil && !containsPermission(c.Scopes, permission) {
+		return false
+	}
+
+	return accessLevel.HasPermission(permission)
+}
+
+func containsPermission(permissions []Permission, permission Permission) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GenerateOpaqueToken returns a random, url safe token suitable for refresh tokens and api keys.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex encoded sha256 of an opaque token. Only the hash is persisted.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
 
-	var authorizerContext AuthorizerContext
-	err := json.Unmarshal([]byte(r.Header.Get("Request-Context")), &authorizerContext)
+// IsSystemAdmin reports whether the user is listed in SYSTEM_ADMIN_USER_IDS, a comma separated list of user ids.
+func IsSystemAdmin(userId string) bool {
+	for _, id := range strings.Split(os.Getenv("SYSTEM_ADMIN_USER_IDS"), ",") {
+		if userId != "" && strings.TrimSpace(id) == userId {
+			return true
+		}
+	}
 
-	logger.Get().Debug("Returning Authorizer Context.")
-	return authorizerContext, err
+	return false
 }
@@ -31,3 +31,55 @@ func ConnectPostgres() *sql.DB {
 	logger.Get().Debug("Connection to postgres database was successful.")
 	return db
 }
+
+// TenantRole is the role tenant transactions run as. Row level security policies on tenant
+// scoped tables apply to it and only show rows of the tenant in TenantSetting.
+const (
+	TenantRole    = "gridiron_tenant"
+	TenantSetting = "app.current_tenant"
+)
+
+// BeginTenantTx starts a transaction that can only see and write rows of the tenant, even
+// when a query forgets to filter by tenant. The role and tenant are reset when the
+// transaction ends, so pooled connections are not left scoped to the tenant.
+func BeginTenantTx(db *sql.DB, tenantId string) (*sql.Tx, error) {
+	if tenantId == "" {
+		return nil, fmt.Errorf("tenant id is required")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Get().Error("Failed to start transaction:", zap.Error(err))
+		return nil, err
+	}
+
+	err = ScopeTenantTx(tx, tenantId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// ScopeTenantTx scopes the rest of an open transaction to the tenant, like BeginTenantTx. It is
+// for transactions that first write rows no tenant can, such as the tenant itself.
+func ScopeTenantTx(tx *sql.Tx, tenantId string) error {
+	if tenantId == "" {
+		return fmt.Errorf("tenant id is required")
+	}
+
+	_, err := tx.Exec("SET LOCAL ROLE " + TenantRole)
+	if err != nil {
+		logger.Get().Error("Failed to set tenant role.", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.Exec("SELECT set_config($1, $2, true)", TenantSetting, tenantId)
+	if err != nil {
+		logger.Get().Error("Failed to set current tenant.", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package league
+
+import (
+	"embed"
+	"errors"
+	"regexp"
+)
+
+// Constants
+
+const (
+	Nfl     = "nfl"
+	NcaaFbs = "ncaa-fbs"
+	Cfl     = "cfl"
+	Empty   = "empty"
+	Custom  = "custom"
+
+	// DefaultTemplate seeds tenants created without choosing a template.
+	DefaultTemplate = Nfl
+
+	MaxTeams              = 256
+	MaxNameLength         = 255
+	MaxAbbreviationLength = 5
+	MaxColors             = 4
+	MinFoundedYear        = 1800
+)
+
+var (
+	templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	colorPattern        = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+	abbreviationPattern = regexp.MustCompile(`^[A-Z0-9]{1,5}$`)
+)
+
+// builtinTemplates are the league definitions shipped with the binary.
+//
+//go:embed templates/*.yaml
+var builtinTemplates embed.FS
+
+// Errors
+
+var (
+	ErrUnknownTemplate = errors.New("league template is unknown")
+	ErrInvalidTemplate = errors.New("league template is invalid")
+)
+
+// Data Transfer Objects
+
+type TemplateGetAllDTO struct {
+	Count int        `json:"count"`
+	Data  []Template `json:"data"`
+}
+
+// Entities
+
+// Template is a league definition used to seed the teams of a new tenant.
+type Template struct {
+	Name        string           `json:"name" yaml:"name"`
+	DisplayName string           `json:"display_name,omitempty" yaml:"display_name"`
+	Teams       []TeamDefinition `json:"teams" yaml:"teams"`
+}
+
+// TeamMetadata is the descriptive data of a team, wherever the team is defined: a tenant's
+// team, a league template team or an archived team.
+type TeamMetadata struct {
+	Name         string
+	Abbreviation string
+	Location     string
+	Nickname     string
+	Venue        string
+	Colors       []string
+	FoundedYear  *int
+}
+
+// TeamDefinition describes a seeded team. The first two colors become the team's primary and
+// secondary color.
+type TeamDefinition struct {
+	Name         string   `json:"name" yaml:"name"`
+	Abbreviation string   `json:"abbreviation,omitempty" yaml:"abbreviation"`
+	Location     string   `json:"location,omitempty" yaml:"location"`
+	Nickname     string   `json:"nickname,omitempty" yaml:"nickname"`
+	Conference   string   `json:"conference,omitempty" yaml:"conference"`
+	Division     string   `json:"division,omitempty" yaml:"division"`
+	Colors       []string `json:"colors,omitempty" yaml:"colors"`
+	Venue        string   `json:"venue,omitempty" yaml:"venue"`
+	FoundedYear  *int     `json:"founded_year,omitempty" yaml:"founded_year"`
+}
@@ -0,0 +1,290 @@
+package league
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ccthomas/gridiron/pkg/logger"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	templatesMu sync.RWMutex
+	templates   map[string]Template
+)
+
+// Load reads the built in league definitions and then any YAML or JSON definitions in
+// dir, which replace built in definitions of the same name. Every definition is
+// validated and nothing is replaced unless all of them are valid. An empty dir only
+// loads the built in definitions.
+func Load(dir string) error {
+	logger.Get().Debug("Load league templates.", zap.String("Dir", dir))
+
+	loaded := map[string]Template{}
+	err := loadDir(builtinTemplates, "templates", loaded)
+	if err != nil {
+		return err
+	}
+
+	if dir != "" {
+		err = loadDir(os.DirFS(dir), ".", loaded)
+		if err != nil {
+			return err
+		}
+	}
+
+	templatesMu.Lock()
+	templates = loaded
+	templatesMu.Unlock()
+
+	logger.Get().Info("Loaded league templates.", zap.Int("Count", len(loaded)))
+	return nil
+}
+
+// LoadFromEnv loads the league definitions with the user supplied ones read from LEAGUE_TEMPLATE_DIR.
+func LoadFromEnv() error {
+	return Load(os.Getenv("LEAGUE_TEMPLATE_DIR"))
+}
+
+func loadDir(fsys fs.FS, dir string, loaded map[string]Template) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read league template dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read league template %s: %w", entry.Name(), err)
+		}
+
+		t, err := parse(data, ext)
+		if err != nil {
+			return fmt.Errorf("failed to parse league template %s: %w", entry.Name(), err)
+		}
+
+		if !templateNamePattern.MatchString(t.Name) || t.Name == Custom {
+			return fmt.Errorf("%s: %w: name must be lowercase letters, digits and dashes and not %q", entry.Name(), ErrInvalidTemplate, Custom)
+		}
+
+		err = t.Validate()
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		if _, ok := loaded[t.Name]; ok {
+			logger.Get().Info("League template replaced.", zap.String("Name", t.Name), zap.String("File", entry.Name()))
+		}
+
+		loaded[t.Name] = t
+	}
+
+	return nil
+}
+
+func parse(data []byte, ext string) (Template, error) {
+	var t Template
+	if ext == ".json" {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&t)
+		return t, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&t)
+	return t, err
+}
+
+// loadedTemplates returns the loaded definitions, loading the built in ones if Load was never called.
+func loadedTemplates() map[string]Template {
+	templatesMu.RLock()
+	loaded := templates
+	templatesMu.RUnlock()
+
+	if loaded != nil {
+		return loaded
+	}
+
+	err := Load("")
+	if err != nil {
+		logger.Get().Fatal("Built in league templates are invalid.", zap.Error(err))
+	}
+
+	templatesMu.RLock()
+	defer templatesMu.RUnlock()
+	return templates
+}
+
+// All returns every loaded template ordered by name.
+func All() []Template {
+	all := []Template{}
+	for _, t := range loadedTemplates() {
+		all = append(all, copyTemplate(t))
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	return all
+}
+
+// Lookup returns the loaded template with the given name.
+func Lookup(name string) (Template, error) {
+	t, ok := loadedTemplates()[name]
+	if !ok {
+		return Template{}, ErrUnknownTemplate
+	}
+
+	return copyTemplate(t), nil
+}
+
+func copyTemplate(t Template) Template {
+	t.Teams = append([]TeamDefinition{}, t.Teams...)
+	return t
+}
+
+// Resolve picks the template a new tenant is seeded from. An empty name selects the
+// default template and Custom selects the custom definition, which is validated.
+func Resolve(name string, custom *Template) (Template, error) {
+	if name == "" {
+		name = DefaultTemplate
+	}
+
+	if name != Custom {
+		return Lookup(name)
+	}
+
+	if custom == nil {
+		return Template{}, fmt.Errorf("%w: custom definition is missing", ErrInvalidTemplate)
+	}
+
+	err := custom.Validate()
+	if err != nil {
+		return Template{}, err
+	}
+
+	return *custom, nil
+}
+
+// Validate checks the template has a name and at most MaxTeams uniquely named teams,
+// that abbreviations are unique and that each team passes ValidateTeamMetadata.
+func (t Template) Validate() error {
+	if strings.TrimSpace(t.Name) == "" || len(t.Name) > MaxNameLength {
+		return fmt.Errorf("%w: name must be between 1 and %d characters", ErrInvalidTemplate, MaxNameLength)
+	}
+
+	if len(t.DisplayName) > MaxNameLength {
+		return fmt.Errorf("%w: display name must be at most %d characters", ErrInvalidTemplate, MaxNameLength)
+	}
+
+	if len(t.Teams) > MaxTeams {
+		return fmt.Errorf("%w: at most %d teams are allowed", ErrInvalidTemplate, MaxTeams)
+	}
+
+	names := map[string]bool{}
+	abbreviations := map[string]bool{}
+	for _, team := range t.Teams {
+		name := strings.TrimSpace(team.Name)
+		if name == "" || len(name) > MaxNameLength {
+			return fmt.Errorf("%w: team names must be between 1 and %d characters", ErrInvalidTemplate, MaxNameLength)
+		}
+
+		if names[strings.ToLower(name)] {
+			return fmt.Errorf("%w: team %q is listed more than once", ErrInvalidTemplate, name)
+		}
+
+		names[strings.ToLower(name)] = true
+
+		err := ValidateTeamMetadata(TeamMetadata{
+			Name:         team.Name,
+			Abbreviation: team.Abbreviation,
+			Location:     team.Location,
+			Nickname:     team.Nickname,
+			Venue:        team.Venue,
+			Colors:       team.Colors,
+			FoundedYear:  team.FoundedYear,
+		})
+		if err != nil {
+			return fmt.Errorf("%w: team %q: %v", ErrInvalidTemplate, name, err)
+		}
+
+		if team.Abbreviation != "" {
+			if abbreviations[strings.ToUpper(team.Abbreviation)] {
+				return fmt.Errorf("%w: abbreviation %q is used more than once", ErrInvalidTemplate, team.Abbreviation)
+			}
+
+			abbreviations[strings.ToUpper(team.Abbreviation)] = true
+		}
+
+		if len(team.Conference) > MaxNameLength || len(team.Division) > MaxNameLength {
+			return fmt.Errorf("%w: conference and division of team %q must be at most %d characters", ErrInvalidTemplate, name, MaxNameLength)
+		}
+
+		if team.Division != "" && team.Conference == "" {
+			return fmt.Errorf("%w: team %q has a division but no conference", ErrInvalidTemplate, name)
+		}
+
+		if len(team.Colors) > MaxColors {
+			return fmt.Errorf("%w: team %q has more than %d colors", ErrInvalidTemplate, name, MaxColors)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTeamMetadata checks the fields every team has, however it is defined. The
+// abbreviation is checked upper cased, as it is stored. The error message is suitable
+// for the api response.
+func ValidateTeamMetadata(m TeamMetadata) error {
+	if strings.TrimSpace(m.Name) == "" || len(m.Name) > MaxNameLength {
+		return fmt.Errorf("Name must be between 1 and %d characters.", MaxNameLength)
+	}
+
+	if m.Abbreviation != "" && !abbreviationPattern.MatchString(strings.ToUpper(m.Abbreviation)) {
+		return fmt.Errorf("Abbreviation must be 1 to %d letters or digits.", MaxAbbreviationLength)
+	}
+
+	if len(m.Location) > MaxNameLength || len(m.Nickname) > MaxNameLength || len(m.Venue) > MaxNameLength {
+		return fmt.Errorf("Location, nickname and venue must be at most %d characters.", MaxNameLength)
+	}
+
+	for _, color := range m.Colors {
+		if color != "" && !IsColor(color) {
+			return errors.New("Colors must be hex codes such as #A71930.")
+		}
+	}
+
+	if m.FoundedYear != nil && (*m.FoundedYear < MinFoundedYear || *m.FoundedYear > time.Now().Year()) {
+		return fmt.Errorf("Founding year must be between %d and %d.", MinFoundedYear, time.Now().Year())
+	}
+
+	return nil
+}
+
+// IsColor reports whether the color is a hex code such as #A71930.
+func IsColor(color string) bool {
+	return colorPattern.MatchString(color)
+}
@@ -2,6 +2,7 @@ package myhttp
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"time"
 
@@ -27,3 +28,13 @@ func WriteError(w http.ResponseWriter, status int, message string) {
 		return
 	}
 }
+
+// ClientIp returns the ip address of the caller from the connection's remote address.
+func ClientIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
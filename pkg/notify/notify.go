@@ -0,0 +1,18 @@
+package notify
+
+type Message struct {
+	Recipient string `json:"recipient"`
+	Subject   string `json:"subject"`
+	Body      string `json:"body"`
+}
+
+// Notifier delivers messages to users. Implementations are selected by NewNotifier.
+type Notifier interface {
+	Send(message Message) error
+}
+
+type LogNotifier struct{}
+
+type FileNotifier struct {
+	Path string
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ccthomas/gridiron/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// NewNotifier constructs the notifier configured by the NOTIFIER environment variable.
+// "file" appends messages to NOTIFIER_FILE_PATH, anything else logs them.
+func NewNotifier() Notifier {
+	switch os.Getenv("NOTIFIER") {
+	case "file":
+		logger.Get().Debug("Constructing file notifier.")
+		return &FileNotifier{Path: os.Getenv("NOTIFIER_FILE_PATH")}
+	default:
+		logger.Get().Debug("Constructing log notifier.")
+		return &LogNotifier{}
+	}
+}
+
+// Send writes the message to the application log. Intended for local development only.
+func (n *LogNotifier) Send(message Message) error {
+	logger.Get().Info("Notification sent.",
+		zap.String("Recipient", message.Recipient),
+		zap.String("Subject", message.Subject),
+		zap.String("Body", message.Body),
+	)
+	return nil
+}
+
+var fileMutex sync.Mutex
+
+// Send appends the message to the file as a JSON line. Intended for local development only.
+func (n *FileNotifier) Send(message Message) error {
+	logger.Get().Debug("Write notification to file.", zap.String("Path", n.Path))
+
+	b, err := json.Marshal(struct {
+		Message
+		SentAt string `json:"sent_at"`
+	}{
+		Message: message,
+		SentAt:  time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+
+	f, err := os.OpenFile(n.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,63 @@
+package oidc
+
+import (
+	"crypto"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Constants
+
+const (
+	// LoginStateDuration is how long a user has to complete the login at the identity provider.
+	LoginStateDuration = 10 * time.Minute
+)
+
+// Data Transfer Objects
+
+// Discovery is the subset of the provider's openid-configuration document used by the login flow.
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IdToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// IdTokenClaims are the verified claims of an id token.
+type IdTokenClaims struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// Entities
+
+type Config struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
+// Provider runs the authorization code flow with PKCE against an OpenID Connect provider.
+// Discovery and signing keys are fetched on first use and cached.
+type Provider struct {
+	Config     Config
+	HttpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      map[string]crypto.PublicKey
+}
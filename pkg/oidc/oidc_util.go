@@ -0,0 +1,270 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ccthomas/gridiron/pkg/logger"
+	"github.com/ccthomas/gridiron/pkg/signing"
+	"github.com/golang-jwt/jwt"
+	"go.uber.org/zap"
+)
+
+// ConfigFromEnv reads the provider configuration from OIDC_ISSUER, OIDC_CLIENT_ID,
+// OIDC_CLIENT_SECRET, OIDC_REDIRECT_URL and OIDC_SCOPES. It returns false when
+// OIDC_ISSUER is not set.
+func ConfigFromEnv() (Config, bool) {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return Config{}, false
+	}
+
+	scopes := strings.Fields(os.Getenv("OIDC_SCOPES"))
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return Config{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientId:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectUrl:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       scopes,
+	}, true
+}
+
+func NewProvider(config Config) *Provider {
+	logger.Get().Debug("Construct oidc provider.", zap.String("Issuer", config.Issuer))
+	return &Provider{
+		Config:     config,
+		HttpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GeneratePkce returns a code verifier and its S256 code challenge (RFC 7636).
+func GeneratePkce() (string, string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// AuthCodeUrl returns the provider url the user is sent to in order to log in.
+func (p *Provider) AuthCodeUrl(state string, nonce string, codeChallenge string) (string, error) {
+	discovery, err := p.Discovery()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.Config.ClientId)
+	query.Set("redirect_uri", p.Config.RedirectUrl)
+	query.Set("scope", strings.Join(p.Config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems the authorization code, proving possession of the PKCE code verifier.
+func (p *Provider) Exchange(code string, codeVerifier string) (*TokenResponse, error) {
+	discovery, err := p.Discovery()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.Config.RedirectUrl)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.Config.ClientId), url.QueryEscape(p.Config.ClientSecret))
+
+	logger.Get().Debug("Exchange authorization code.")
+	res, err := p.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %d", res.StatusCode)
+	}
+
+	var tokenResponse TokenResponse
+	err = json.NewDecoder(res.Body).Decode(&tokenResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenResponse.IdToken == "" {
+		return nil, fmt.Errorf("token response is missing id token")
+	}
+
+	return &tokenResponse, nil
+}
+
+// VerifyIdToken checks the id token's signature, issuer, audience, expiry and nonce.
+func (p *Provider) VerifyIdToken(rawIdToken string, nonce string) (*IdTokenClaims, error) {
+	parser := &jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384"}}
+
+	token, err := parser.Parse(rawIdToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("id token claims are invalid")
+	}
+
+	if !claims.VerifyIssuer(p.Config.Issuer, true) {
+		return nil, fmt.Errorf("id token issuer is invalid")
+	}
+
+	if !claims.VerifyAudience(p.Config.ClientId, true) {
+		return nil, fmt.Errorf("id token audience is invalid")
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("id token is missing exp")
+	}
+
+	if claimNonce, _ := claims["nonce"].(string); claimNonce == "" || claimNonce != nonce {
+		return nil, fmt.Errorf("id token nonce is invalid")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("id token is missing sub")
+	}
+
+	idTokenClaims := &IdTokenClaims{
+		Issuer:  p.Config.Issuer,
+		Subject: subject,
+	}
+	idTokenClaims.Email, _ = claims["email"].(string)
+	idTokenClaims.EmailVerified, _ = claims["email_verified"].(bool)
+	idTokenClaims.Name, _ = claims["name"].(string)
+	idTokenClaims.PreferredUsername, _ = claims["preferred_username"].(string)
+
+	return idTokenClaims, nil
+}
+
+// Discovery fetches and caches the provider's openid-configuration document.
+func (p *Provider) Discovery() (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	logger.Get().Debug("Fetch oidc discovery document.", zap.String("Issuer", p.Config.Issuer))
+	var discovery Discovery
+	err := p.getJson(p.Config.Issuer+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.Config.Issuer {
+		return nil, fmt.Errorf("discovery issuer %s does not match %s", discovery.Issuer, p.Config.Issuer)
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// publicKey returns the provider key with the kid, refreshing the cached keys once
+// when the kid is unknown so provider key rotation is picked up.
+func (p *Provider) publicKey(kid string) (crypto.PublicKey, error) {
+	discovery, err := p.Discovery()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	logger.Get().Debug("Fetch oidc provider keys.", zap.String("Kid", kid))
+	var jwks signing.JwkSet
+	err = p.getJson(discovery.JwksUri, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.PublicKey()
+		if err != nil {
+			logger.Get().Warn("Skipping unsupported oidc provider key.", zap.String("Kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	p.keys = keys
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %s", kid)
+	}
+
+	return key, nil
+}
+
+func (p *Provider) getJson(url string, v any) error {
+	res, err := p.HttpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -54,6 +54,11 @@ func (r *RabbitMqRouter) handleFuncAsync(exchange string, key string, f func(bod
 
 	go func() {
 		for d := range msgs {
+			// Exchanges are fanout, so every queue receives every message on the exchange.
+			if d.RoutingKey != key {
+				continue
+			}
+
 			logger.Get().Debug("Message received from queue.", zap.String("Exchange", exchange), zap.String("Key", key))
 
 			logger.Get().Debug("Unmarshal rabbit mq body")
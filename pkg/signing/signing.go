@@ -0,0 +1,40 @@
+package signing
+
+import (
+	"crypto"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Key is a key pair used to sign and verify tokens, identified by its kid.
+// Retired keys only have a public key and can verify, but not sign.
+type Key struct {
+	Kid        string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// KeySet holds every key tokens may be verified with and the one new tokens are signed with.
+type KeySet struct {
+	Issuer     string
+	SigningKid string
+	Keys       map[string]*Key
+}
+
+// Jwk is a public key in JSON Web Key format (RFC 7517).
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JwkSet struct {
+	Keys []Jwk `json:"keys"`
+}
@@ -0,0 +1,302 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ccthomas/gridiron/pkg/logger"
+	"github.com/golang-jwt/jwt"
+	"go.uber.org/zap"
+)
+
+// LoadKeySetFromEnv loads the key set from the PEM files in JWT_KEYS_DIR and signs with
+// JWT_SIGNING_KID. When JWT_KEYS_DIR is unset an ephemeral key is generated, which is only
+// suitable for local development since tokens stop verifying on restart.
+func LoadKeySetFromEnv() (*KeySet, error) {
+	issuer := os.Getenv("JWT_ISSUER")
+	if issuer == "" {
+		issuer = "gridiron"
+	}
+
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		logger.Get().Warn("JWT_KEYS_DIR is not set, generating an ephemeral signing key.")
+		return NewEphemeralKeySet(issuer)
+	}
+
+	return LoadKeySet(dir, os.Getenv("JWT_SIGNING_KID"), issuer)
+}
+
+// LoadKeySet reads every <kid>.pem private key and <kid>.pub.pem public key in dir.
+// RSA keys sign with RS256 and P-256 keys with ES256. When signingKid is empty the
+// private key with the greatest kid, in lexical order, signs new tokens.
+func LoadKeySet(dir string, signingKid string, issuer string) (*KeySet, error) {
+	logger.Get().Debug("Load key set.", zap.String("Dir", dir))
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+
+	keySet := &KeySet{
+		Issuer: issuer,
+		Keys:   map[string]*Key{},
+	}
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var key *Key
+		if strings.HasSuffix(name, ".pub.pem") {
+			key, err = parsePublicKey(strings.TrimSuffix(name, ".pub.pem"), b)
+		} else {
+			key, err = parsePrivateKey(strings.TrimSuffix(name, ".pem"), b)
+			if err == nil && signingKid == "" {
+				keySet.SigningKid = key.Kid
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s: %w", name, err)
+		}
+
+		if _, ok := keySet.Keys[key.Kid]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", key.Kid)
+		}
+
+		logger.Get().Debug("Loaded key.", zap.String("Kid", key.Kid), zap.String("Alg", key.Method.Alg()))
+		keySet.Keys[key.Kid] = key
+	}
+
+	if signingKid != "" {
+		keySet.SigningKid = signingKid
+	}
+
+	signingKey, ok := keySet.Keys[keySet.SigningKid]
+	if !ok || signingKey.PrivateKey == nil {
+		return nil, fmt.Errorf("no private key found for signing key id %q", keySet.SigningKid)
+	}
+
+	return keySet, nil
+}
+
+// NewEphemeralKeySet returns a key set with a single, freshly generated ES256 key.
+func NewEphemeralKeySet(issuer string) (*KeySet, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := "ephemeral"
+	return &KeySet{
+		Issuer:     issuer,
+		SigningKid: kid,
+		Keys: map[string]*Key{
+			kid: {
+				Kid:        kid,
+				Method:     jwt.SigningMethodES256,
+				PrivateKey: privateKey,
+				PublicKey:  &privateKey.PublicKey,
+			},
+		},
+	}, nil
+}
+
+// Sign signs the claims with the signing key, setting the issuer and kid.
+func (k *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	key := k.Keys[k.SigningKid]
+
+	claims["iss"] = k.Issuer
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+
+	return token.SignedString(key.PrivateKey)
+}
+
+// Parse verifies the token against the key named by its kid. The token's algorithm
+// must match the key's algorithm exactly, so a token cannot choose how it is verified.
+func (k *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	parser := &jwt.Parser{ValidMethods: k.algorithms()}
+
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("token is missing kid")
+		}
+
+		key, ok := k.Keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %s", kid)
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for kid %s", token.Method.Alg(), kid)
+		}
+
+		return key.PublicKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !claims.VerifyIssuer(k.Issuer, true) {
+		return nil, fmt.Errorf("token issuer is invalid")
+	}
+
+	return token, nil
+}
+
+// Jwks returns the public keys in JSON Web Key Set format.
+func (k *KeySet) Jwks() JwkSet {
+	kids := make([]string, 0, len(k.Keys))
+	for kid := range k.Keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := JwkSet{Keys: []Jwk{}}
+	for _, kid := range kids {
+		key := k.Keys[kid]
+		jwk := Jwk{
+			Kid: key.Kid,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+		}
+
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (publicKey.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = publicKey.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, size)))
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// PublicKey decodes the RSA or EC public key the jwk describes.
+func (j Jwk) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", j.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", j.Kty)
+	}
+}
+
+func (k *KeySet) algorithms() []string {
+	seen := map[string]bool{}
+	var algorithms []string
+	for _, key := range k.Keys {
+		if !seen[key.Method.Alg()] {
+			seen[key.Method.Alg()] = true
+			algorithms = append(algorithms, key.Method.Alg())
+		}
+	}
+
+	return algorithms
+}
+
+func parsePrivateKey(kid string, b []byte) (*Key, error) {
+	if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(b); err == nil {
+		return &Key{Kid: kid, Method: jwt.SigningMethodRS256, PrivateKey: rsaKey, PublicKey: &rsaKey.PublicKey}, nil
+	}
+
+	ecKey, err := jwt.ParseECPrivateKeyFromPEM(b)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported private key, expected RSA or EC")
+	}
+
+	method, err := ecMethod(ecKey.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{Kid: kid, Method: method, PrivateKey: ecKey, PublicKey: &ecKey.PublicKey}, nil
+}
+
+func parsePublicKey(kid string, b []byte) (*Key, error) {
+	if rsaKey, err := jwt.ParseRSAPublicKeyFromPEM(b); err == nil {
+		return &Key{Kid: kid, Method: jwt.SigningMethodRS256, PublicKey: rsaKey}, nil
+	}
+
+	ecKey, err := jwt.ParseECPublicKeyFromPEM(b)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported public key, expected RSA or EC")
+	}
+
+	method, err := ecMethod(ecKey.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{Kid: kid, Method: method, PublicKey: ecKey}, nil
+}
+
+func ecMethod(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	switch curve {
+	case elliptic.P256():
+		return jwt.SigningMethodES256, nil
+	case elliptic.P384():
+		return jwt.SigningMethodES384, nil
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", curve.Params().Name)
+	}
+}
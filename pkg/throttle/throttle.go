@@ -0,0 +1,47 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// StoreErrorDelay is returned by RetryAfter when the attempt state cannot be read, so
+// attempts fail closed while the store is unavailable.
+const StoreErrorDelay = 5 * time.Second
+
+// Policy controls how failed attempts are slowed down and locked out.
+type Policy struct {
+	// FreeAttempts is the number of failures allowed before backoff starts.
+	FreeAttempts int
+	// BaseDelay is the first backoff delay. Each further failure doubles it.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay.
+	MaxDelay time.Duration
+	// LockoutThreshold is the number of failures that locks the key.
+	LockoutThreshold int
+	// LockoutDuration is how long a locked key stays locked. Failures older
+	// than this are also forgotten.
+	LockoutDuration time.Duration
+}
+
+type State struct {
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+// Store persists attempt state so it survives restarts and is shared between instances.
+type Store interface {
+	SelectAttempt(key string) (*State, error)
+	UpsertAttempt(key string, state State) error
+	DeleteAttempt(key string) error
+}
+
+// Tracker counts failed attempts per key. State is kept in memory unless a Store is provided.
+type Tracker struct {
+	Policy Policy
+	Store  Store
+
+	mu     sync.Mutex
+	states map[string]State
+}
@@ -0,0 +1,171 @@
+package throttle
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ccthomas/gridiron/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func NewTracker(policy Policy, store Store) *Tracker {
+	logger.Get().Debug("Constructing throttle tracker.")
+	return &Tracker{
+		Policy: policy,
+		Store:  store,
+		states: map[string]State{},
+	}
+}
+
+// PolicyFromEnv reads <prefix>FREE_ATTEMPTS, <prefix>BACKOFF_BASE, <prefix>BACKOFF_MAX,
+// <prefix>LOCKOUT_THRESHOLD and <prefix>LOCKOUT_DURATION, keeping the defaults for
+// anything unset or invalid. Durations use time.ParseDuration syntax.
+func PolicyFromEnv(prefix string, defaults Policy) Policy {
+	policy := defaults
+	policy.FreeAttempts = intFromEnv(prefix+"FREE_ATTEMPTS", defaults.FreeAttempts)
+	policy.BaseDelay = durationFromEnv(prefix+"BACKOFF_BASE", defaults.BaseDelay)
+	policy.MaxDelay = durationFromEnv(prefix+"BACKOFF_MAX", defaults.MaxDelay)
+	policy.LockoutThreshold = intFromEnv(prefix+"LOCKOUT_THRESHOLD", defaults.LockoutThreshold)
+	policy.LockoutDuration = durationFromEnv(prefix+"LOCKOUT_DURATION", defaults.LockoutDuration)
+	return policy
+}
+
+// RetryAfter returns how long the caller must wait before the key may be attempted again.
+// Zero means an attempt is allowed now. StoreErrorDelay is returned when the state cannot be read.
+func (t *Tracker) RetryAfter(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	state, err := t.load(key, now)
+	if err != nil {
+		return StoreErrorDelay
+	}
+
+	if state.LockedUntil != nil && now.Before(*state.LockedUntil) {
+		return state.LockedUntil.Sub(now)
+	}
+
+	delay := t.backoff(state.Failures)
+	if next := state.LastFailureAt.Add(delay); now.Before(next) {
+		return next.Sub(now)
+	}
+
+	return 0
+}
+
+// Fail records a failed attempt. It returns true when this failure locked the key. Nothing
+// is recorded when the stored state cannot be read, so an existing lock is never overwritten.
+func (t *Tracker) Fail(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	state, err := t.load(key, now)
+	if err != nil {
+		return false
+	}
+	wasLocked := state.LockedUntil != nil && now.Before(*state.LockedUntil)
+
+	state.Failures++
+	state.LastFailureAt = now
+
+	locked := false
+	if !wasLocked && t.Policy.LockoutThreshold > 0 && state.Failures >= t.Policy.LockoutThreshold {
+		lockedUntil := now.Add(t.Policy.LockoutDuration)
+		state.LockedUntil = &lockedUntil
+		locked = true
+	}
+
+	t.save(key, state)
+	return locked
+}
+
+// Reset forgets every failure for the key. Used on success and when an admin unlocks an account.
+func (t *Tracker) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.states, key)
+	if t.Store != nil {
+		err := t.Store.DeleteAttempt(key)
+		if err != nil {
+			logger.Get().Error("Failed to delete attempt state.", zap.Error(err))
+		}
+	}
+}
+
+func (t *Tracker) backoff(failures int) time.Duration {
+	over := failures - t.Policy.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+
+	delay := t.Policy.BaseDelay
+	for i := 1; i < over && delay < t.Policy.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > t.Policy.MaxDelay {
+		delay = t.Policy.MaxDelay
+	}
+
+	return delay
+}
+
+// load returns the state for the key, forgetting it once it has gone stale.
+func (t *Tracker) load(key string, now time.Time) (State, error) {
+	var state State
+	if t.Store != nil {
+		stored, err := t.Store.SelectAttempt(key)
+		if err != nil {
+			logger.Get().Error("Failed to select attempt state.", zap.Error(err))
+			return State{}, err
+		}
+
+		if stored != nil {
+			state = *stored
+		}
+	} else {
+		state = t.states[key]
+	}
+
+	stale := now.Sub(state.LastFailureAt) > t.Policy.LockoutDuration
+	locked := state.LockedUntil != nil && now.Before(*state.LockedUntil)
+	if stale && !locked {
+		return State{}, nil
+	}
+
+	return state, nil
+}
+
+func (t *Tracker) save(key string, state State) {
+	if t.Store == nil {
+		t.states[key] = state
+		return
+	}
+
+	err := t.Store.UpsertAttempt(key, state)
+	if err != nil {
+		logger.Get().Error("Failed to upsert attempt state.", zap.Error(err))
+	}
+}
+
+func intFromEnv(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
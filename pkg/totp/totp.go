@@ -0,0 +1,20 @@
+package totp
+
+import "time"
+
+// Constants
+
+const (
+	// Period is the number of seconds each code is valid for.
+	Period = 30 * time.Second
+
+	// Digits is the length of each code.
+	Digits = 6
+
+	// Skew is the number of periods either side of now a code is still accepted in,
+	// to allow for clock drift between the server and the authenticator.
+	Skew = 1
+
+	// SecretSize is the number of random bytes in a secret (RFC 4226 recommends 160 bits).
+	SecretSize = 20
+)
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ccthomas/gridiron/internal/tenant"
+	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/database"
+	"github.com/ccthomas/gridiron/pkg/myhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImportTenant(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	viewer := createUser(t)
+	tn := createTenant(t, owner.Id, "TestArchive")
+	createTenantUserAccess(t, tn.Id, viewer.Id, auth.Viewer)
+	teamOne := createTeam(t, tn.Id, "Team One")
+	teamTwo := createTeam(t, tn.Id, "Team Two")
+
+	res, archive := sendApiReq[tenant.TenantArchiveDTO](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/export", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, tenant.ArchiveSchemaVersion, archive.SchemaVersion, "Schema version is incorrect")
+	assert.Equal(t, tn, archive.Tenant, "Tenant is incorrect")
+	assert.Equal(t, 2, len(archive.Members), "Members are incorrect")
+	assert.Equal(t, 2, len(archive.Teams), "Teams are incorrect")
+
+	// When
+
+	res, actual := sendApiReq[tenant.TenantImportDTO](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/tenant/import",
+		archive,
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	cleanUpTenant(t, actual.Tenant.Id)
+
+	assert.NotEqual(t, tn.Id, actual.Tenant.Id, "Imported tenant reused the tenant id")
+	assert.Equal(t, tn.Name, actual.Tenant.Name, "Tenant name is incorrect")
+	assert.Equal(t, 2, actual.Teams, "Team count is incorrect")
+	assert.Equal(t, 1, actual.Invitations, "Invitation count is incorrect")
+
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	var reusedIds int
+	err := db.QueryRow("SELECT COUNT(*) FROM team.team WHERE tenant_id = $1 AND id IN ($2, $3)", actual.Tenant.Id, teamOne.Id, teamTwo.Id).Scan(&reusedIds)
+	if err != nil {
+		t.Fatal("Failed to count teams.", err.Error())
+	}
+	assert.Equal(t, 0, reusedIds, "Imported teams did not get fresh ids")
+
+	var members int
+	err = db.QueryRow("SELECT COUNT(*) FROM tenant.tenant_user_access WHERE tenant_id = $1 AND user_account_id = $2", actual.Tenant.Id, viewer.Id).Scan(&members)
+	if err != nil {
+		t.Fatal("Failed to count viewer access.", err.Error())
+	}
+	assert.Equal(t, 0, members, "Viewer was added without accepting an invitation")
+
+	var accessLevel auth.AccessLevel
+	var status tenant.InvitationStatus
+	err = db.QueryRow("SELECT access_level, status FROM tenant.tenant_invitation WHERE tenant_id = $1 AND user_account_id = $2", actual.Tenant.Id, viewer.Id).Scan(&accessLevel, &status)
+	if err != nil {
+		t.Fatal("Failed to select viewer invitation.", err.Error())
+	}
+	assert.Equal(t, auth.Viewer, accessLevel, "Viewer invitation access level is incorrect")
+	assert.Equal(t, tenant.InvitationPending, status, "Viewer invitation is not pending")
+}
+
+func TestImportTenant_UnsupportedVersion(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+	archive := tenant.TenantArchiveDTO{
+		SchemaVersion: "2.0.0",
+		Tenant:        tenant.Tenant{Name: "TestArchive"},
+	}
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/tenant/import",
+		archive,
+		loginRes.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "Archive schema version is not supported.", startTime, endTime)
+}
+
+func TestImportTenant_InvalidTeamMetadata(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+	foundedYear := 1700
+	archive := tenant.TenantArchiveDTO{
+		SchemaVersion: tenant.ArchiveSchemaVersion,
+		Tenant:        tenant.Tenant{Name: "TestArchive"},
+		Teams: []tenant.ArchiveTeam{
+			{Id: "one", Name: "Team One", Abbreviation: "T-1"},
+			{Id: "two", Name: "Team Two", FoundedYear: &foundedYear},
+		},
+	}
+
+	for _, team := range archive.Teams {
+		invalid := archive
+		invalid.Teams = []tenant.ArchiveTeam{team}
+
+		// When
+
+		startTime := time.Now().UTC()
+		res, actual := sendApiReq[myhttp.ApiError](
+			t,
+			http.MethodPost,
+			"http://localhost:8080/tenant/import",
+			invalid,
+			loginRes.AccessToken,
+			"",
+		)
+		endTime := time.Now().UTC()
+
+		// Then
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+		assertApiError(t, actual, "Archive is invalid.", startTime, endTime)
+	}
+}
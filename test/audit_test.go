@@ -0,0 +1,189 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ccthomas/gridiron/internal/audit"
+	"github.com/ccthomas/gridiron/internal/tenant"
+	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/myhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func auditLogUrl(tenantId string, query url.Values) string {
+	return fmt.Sprintf("http://localhost:8080/tenant/%s/audit-log?%s", tenantId, query.Encode())
+}
+
+func TestAuditLog_RecordsMemberUpdate(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	member := createUser(t)
+	tn := createTenant(t, owner.Id, "TestAuditLog")
+	createTenantUserAccess(t, tn.Id, member.Id, auth.Viewer)
+
+	res, _ := sendApiReq[tenant.TenantUserAccess](
+		t,
+		http.MethodPatch,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/members/%s", tn.Id, member.Id),
+		&tenant.UpdateMemberDTO{AccessLevel: auth.Editor},
+		ownerLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	// When
+
+	res, actual := sendApiReq[audit.AuditEventGetAllDTO](
+		t,
+		http.MethodGet,
+		auditLogUrl(tn.Id, url.Values{"actor": {owner.Id}}),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, 1, actual.Count, "count is incorrect.")
+	assert.Equal(t, audit.TenantMemberUpdate, actual.Data[0].Action, "action is incorrect.")
+	assert.Equal(t, audit.Success, actual.Data[0].Outcome, "outcome is incorrect.")
+	assert.Equal(t, owner.Id, *actual.Data[0].ActorUserAccountId, "actor is incorrect.")
+	assert.Equal(t, member.Id, *actual.Data[0].Target, "target is incorrect.")
+	assert.Equal(t, "VIEWER -> EDITOR", *actual.Data[0].Detail, "detail is incorrect.")
+	assert.NotEmpty(t, actual.Data[0].Ip, "ip was not recorded.")
+	assert.NotEmpty(t, actual.Data[0].UserAgent, "user agent was not recorded.")
+}
+
+func TestAuditLog_RecordsPermissionDenied(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	viewer, viewerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestAuditLog")
+	createTenantUserAccess(t, tn.Id, viewer.Id, auth.Viewer)
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/members/%s", tn.Id, viewer.Id),
+		nil,
+		viewerLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+
+	// When
+
+	res, actual := sendApiReq[audit.AuditEventGetAllDTO](
+		t,
+		http.MethodGet,
+		auditLogUrl(tn.Id, url.Values{"actor": {viewer.Id}}),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, 1, actual.Count, "count is incorrect.")
+	assert.Equal(t, audit.TenantPermission, actual.Data[0].Action, "action is incorrect.")
+	assert.Equal(t, audit.Denied, actual.Data[0].Outcome, "outcome is incorrect.")
+	assert.Equal(t, string(auth.TenantManage), *actual.Data[0].Detail, "detail is incorrect.")
+}
+
+func TestAuditLog_FilterByTime(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestAuditLog")
+
+	res, created := sendApiReq[tenant.ApiKeyCreatedDTO](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/api-keys", tn.Id),
+		&tenant.CreateApiKeyDTO{Name: "ingest", Scopes: []auth.Permission{auth.TeamRead}},
+		ownerLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	// When
+
+	_, before := sendApiReq[audit.AuditEventGetAllDTO](
+		t,
+		http.MethodGet,
+		auditLogUrl(tn.Id, url.Values{"to": {created.CreatedAt.Add(-time.Minute).Format(time.RFC3339)}}),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+	_, after := sendApiReq[audit.AuditEventGetAllDTO](
+		t,
+		http.MethodGet,
+		auditLogUrl(tn.Id, url.Values{"from": {created.CreatedAt.Add(-time.Minute).Format(time.RFC3339)}}),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, 0, before.Count, "events before the range were returned.")
+	assert.Equal(t, 1, after.Count, "count is incorrect.")
+	assert.Equal(t, audit.TenantApiKeyCreate, after.Data[0].Action, "action is incorrect.")
+	assert.Equal(t, created.Id, *after.Data[0].Target, "target is incorrect.")
+}
+
+func TestAuditLog_AdminForbidden(t *testing.T) {
+	// Given
+	owner := createUser(t)
+	admin, adminLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestAuditLog")
+	createTenantUserAccess(t, tn.Id, admin.Id, auth.Admin)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		auditLogUrl(tn.Id, url.Values{}),
+		nil,
+		adminLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "User does not have permission to perform this action.", startTime, endTime)
+}
+
+func TestAuditLog_InvalidFrom(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestAuditLog")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		auditLogUrl(tn.Id, url.Values{"from": {"yesterday"}}),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "from must be an RFC 3339 timestamp.", startTime, endTime)
+}
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ccthomas/gridiron/internal/tenant"
+	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/database"
+	"github.com/ccthomas/gridiron/pkg/myhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloneTenant(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestClone")
+	createTeam(t, tn.Id, "Team One")
+	createTeam(t, tn.Id, "Team Two")
+
+	// When
+
+	res, clone := sendApiReq[tenant.TenantClone](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/clone", tn.Id),
+		&tenant.CreateTenantCloneDTO{Name: "TestClone Sandbox"},
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusAccepted, res.StatusCode, "Status code is not a 202")
+	assert.Equal(t, fmt.Sprintf("/tenant/clones/%s", clone.Id), res.Header.Get("Location"), "Location is incorrect")
+	assert.Equal(t, tenant.ClonePending, clone.Status, "Clone status is incorrect")
+	cleanUpTenant(t, clone.TargetTenantId)
+
+	var actual tenant.TenantClone
+	for i := 0; i < 20; i++ {
+		time.Sleep(500 * time.Millisecond)
+
+		res, actual = sendApiReq[tenant.TenantClone](
+			t,
+			http.MethodGet,
+			fmt.Sprintf("http://localhost:8080/tenant/clones/%s", clone.Id),
+			nil,
+			ownerLogin.AccessToken,
+			"",
+		)
+		assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+		if actual.Status == tenant.CloneCompleted || actual.Status == tenant.CloneFailed {
+			break
+		}
+	}
+
+	assert.Equal(t, tenant.CloneCompleted, actual.Status, "Clone did not complete")
+	assert.NotNil(t, actual.CompletedAt, "Completed at is not set")
+
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	var name string
+	err := db.QueryRow("SELECT name FROM tenant.tenant WHERE id = $1", clone.TargetTenantId).Scan(&name)
+	if err != nil {
+		t.Fatal("Cloned tenant was not created.", err.Error())
+	}
+	assert.Equal(t, "TestClone Sandbox", name, "Cloned tenant name is incorrect")
+
+	var teamCount int
+	err = db.QueryRow("SELECT COUNT(*) FROM team.team WHERE tenant_id = $1", clone.TargetTenantId).Scan(&teamCount)
+	if err != nil {
+		t.Fatal("Failed to count teams.", err.Error())
+	}
+	assert.Equal(t, 2, teamCount, "Cloned teams are incorrect")
+
+	var accessLevel auth.AccessLevel
+	err = db.QueryRow("SELECT access_level FROM tenant.tenant_user_access WHERE tenant_id = $1 AND user_account_id = $2", clone.TargetTenantId, owner.Id).Scan(&accessLevel)
+	if err != nil {
+		t.Fatal("Failed to select owner access.", err.Error())
+	}
+	assert.Equal(t, auth.Owner, accessLevel, "Caller does not own the clone")
+}
+
+func TestCloneTenant_TruncatesMultiByteName(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "a"+strings.Repeat("é", 127))
+
+	// When
+
+	res, clone := sendApiReq[tenant.TenantClone](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/clone", tn.Id),
+		&tenant.CreateTenantCloneDTO{},
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusAccepted, res.StatusCode, "Status code is not a 202")
+	cleanUpTenant(t, clone.TargetTenantId)
+
+	assert.True(t, utf8.ValidString(clone.TargetName), "Clone name is not valid UTF-8")
+	assert.LessOrEqual(t, len(clone.TargetName), tenant.MaxTenantNameLength, "Clone name is too long")
+	assert.True(t, strings.HasSuffix(clone.TargetName, " (copy)"), "Clone name is not marked as a copy")
+}
+
+func TestGetClone_OtherUser(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	_, otherLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestClone")
+
+	_, clone := sendApiReq[tenant.TenantClone](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/clone", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+	cleanUpTenant(t, clone.TargetTenantId)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/tenant/clones/%s", clone.Id),
+		nil,
+		otherLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "Status code is not a 404")
+	assertApiError(t, actual, "Clone not found.", startTime, endTime)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/ccthomas/gridiron/internal/team"
 	"github.com/ccthomas/gridiron/internal/tenant"
 	"github.com/ccthomas/gridiron/internal/useracc"
+	"github.com/ccthomas/gridiron/pkg/auth"
 	"github.com/ccthomas/gridiron/pkg/database"
 	"github.com/ccthomas/gridiron/pkg/logger"
 	"github.com/ccthomas/gridiron/pkg/myhttp"
@@ -155,6 +156,12 @@ func cleanUpTenant(t *testing.T, id string) {
 			t.Fatal(err.Error())
 		}
 
+		_, err = db.Exec("DELETE FROM audit.audit_event WHERE tenant_id = $1", id)
+		if err != nil {
+			logger.Get().Error("Failed to clean up audit events.")
+			t.Fatal(err.Error())
+		}
+
 		_, err = db.Exec("DELETE FROM tenant.tenant WHERE id = $1", id)
 		if err != nil {
 			logger.Get().Error("Failed to clean up user.")
@@ -231,7 +238,7 @@ func createUser(t *testing.T) UserAccountWithPass {
 
 	id := uuid.New().String()
 	password := fmt.Sprintf("password%s", id)
-	passwordHash, _ := useracc.HashPassword(password)
+	passwordHash, _ := useracc.DefaultPasswordHashPolicy.Hash(password)
 	user := UserAccountWithPass{
 		Id:           id,
 		Username:     fmt.Sprintf("TestCreateNewUser%s", id),
@@ -277,3 +284,99 @@ func login(t *testing.T) (UserAccountWithPass, useracc.LoginResponseDTO) {
 
 	return existing, loginResponse
 }
+
+func sendApiReqNoContent(
+	t *testing.T,
+	method string,
+	url string,
+	body any,
+	auth string,
+	tenantId string,
+) *http.Response {
+	var reqBody *bytes.Buffer = bytes.NewBuffer(nil)
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal("Failed to marshal body", err.Error())
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		t.Fatal("Failed to construct request", err.Error())
+	}
+
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+		req.Header.Set("x-tenant-id", tenantId)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Api request failed.", err.Error())
+	}
+
+	return res
+}
+
+func createTenantUserAccess(t *testing.T, tenantId string, userId string, accessLevel auth.AccessLevel) {
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	_, err := db.Exec(
+		"INSERT INTO tenant.tenant_user_access (tenant_id, user_account_id, access_level) VALUES ($1, $2, $3)",
+		tenantId, userId, accessLevel,
+	)
+	if err != nil {
+		t.Fatal("Failed to insert tenant user access as a part of setup.", err.Error())
+	}
+}
+
+func sendApiKeyReq(
+	t *testing.T,
+	method string,
+	url string,
+	body any,
+	apiKey string,
+	tenantId string,
+) *http.Response {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal("Failed to marshal body", err.Error())
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		t.Fatal("Failed to construct request", err.Error())
+	}
+
+	req.Header.Set("X-API-Key", apiKey)
+	req.Header.Set("x-tenant-id", tenantId)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Api request failed.", err.Error())
+	}
+
+	return res
+}
+
+func setTenantQuota(t *testing.T, tenantId string, quota tenant.TenantQuota) {
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	_, err := db.Exec(
+		"INSERT INTO tenant.tenant_quota (tenant_id, max_teams, max_players, max_members, max_api_calls_per_day, max_storage_bytes) VALUES ($1, $2, $3, $4, $5, $6)",
+		tenantId, quota.MaxTeams, quota.MaxPlayers, quota.MaxMembers, quota.MaxApiCallsPerDay, quota.MaxStorageBytes,
+	)
+	if err != nil {
+		t.Fatal("Failed to insert tenant quota as a part of setup.", err.Error())
+	}
+}
+
+// verifyPassword reports whether the password matches the stored hash under the default policy.
+func verifyPassword(password string, hash string) bool {
+	match, _ := useracc.DefaultPasswordHashPolicy.Verify(password, hash)
+	return match
+}
@@ -0,0 +1,194 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ccthomas/gridiron/pkg/signing"
+	"github.com/golang-jwt/jwt"
+)
+
+// stubOidcProvider is a stand-in OpenID Connect provider. It listens on the address in
+// OIDC_ISSUER so the Gridiron service can discover it, and plays the part of the user's
+// browser session through Authorize.
+type stubOidcProvider struct {
+	issuer       string
+	clientId     string
+	clientSecret string
+	key          *rsa.PrivateKey
+	kid          string
+
+	mu    sync.Mutex
+	codes map[string]stubAuthorization
+}
+
+type stubAuthorization struct {
+	redirectUri   string
+	nonce         string
+	codeChallenge string
+	subject       string
+	email         string
+	name          string
+}
+
+func startOidcProvider(t *testing.T) *stubOidcProvider {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		t.Skip("OIDC_ISSUER is not configured.")
+	}
+
+	issuerUrl, err := url.Parse(issuer)
+	if err != nil {
+		t.Fatal("OIDC_ISSUER is invalid.", err.Error())
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &stubOidcProvider{
+		issuer:       issuer,
+		clientId:     os.Getenv("OIDC_CLIENT_ID"),
+		clientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		key:          key,
+		kid:          randomCode(t),
+		codes:        map[string]stubAuthorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discoveryHandler)
+	mux.HandleFunc("/jwks", p.jwksHandler)
+	mux.HandleFunc("/token", p.tokenHandler)
+
+	listener, err := net.Listen("tcp", ":"+issuerUrl.Port())
+	if err != nil {
+		t.Fatal("Failed to start stand-in oidc provider.", err.Error())
+	}
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return p
+}
+
+// Authorize approves the authorization request as the given user and returns the
+// authorization code the provider would redirect back with.
+func (p *stubOidcProvider) Authorize(t *testing.T, authorizationUrl string, subject string, email string) (string, string) {
+	u, err := url.Parse(authorizationUrl)
+	if err != nil {
+		t.Fatal("Authorization url is invalid.", err.Error())
+	}
+
+	query := u.Query()
+	if query.Get("client_id") != p.clientId || query.Get("code_challenge_method") != "S256" {
+		t.Fatal("Authorization request is invalid.", authorizationUrl)
+	}
+
+	code := randomCode(t)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.codes[code] = stubAuthorization{
+		redirectUri:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		subject:       subject,
+		email:         email,
+		name:          "Stand-in User",
+	}
+
+	return code, query.Get("state")
+}
+
+func (p *stubOidcProvider) discoveryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 p.issuer,
+		"authorization_endpoint": p.issuer + "/authorize",
+		"token_endpoint":         p.issuer + "/token",
+		"jwks_uri":               p.issuer + "/jwks",
+	})
+}
+
+func (p *stubOidcProvider) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	keySet := signing.KeySet{Keys: map[string]*signing.Key{
+		p.kid: {Kid: p.kid, Method: jwt.SigningMethodRS256, PublicKey: &p.key.PublicKey},
+	}}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keySet.Jwks())
+}
+
+func (p *stubOidcProvider) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	clientId, clientSecret, ok := r.BasicAuth()
+	if !ok || clientId != p.clientId || clientSecret != p.clientSecret {
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+		return
+	}
+
+	if r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	authorization, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok ||
+		authorization.redirectUri != r.PostForm.Get("redirect_uri") ||
+		authorization.codeChallenge != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.issuer,
+		"aud":            p.clientId,
+		"sub":            authorization.subject,
+		"nonce":          authorization.nonce,
+		"email":          authorization.email,
+		"email_verified": true,
+		"name":           authorization.name,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+	})
+	idToken.Header["kid"] = p.kid
+
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		http.Error(w, "server_error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "stand-in-access-token",
+		"token_type":   "Bearer",
+		"id_token":     signed,
+		"expires_in":   300,
+	})
+}
+
+func randomCode(t *testing.T) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
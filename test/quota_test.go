@@ -0,0 +1,189 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ccthomas/gridiron/internal/team"
+	"github.com/ccthomas/gridiron/internal/tenant"
+	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/myhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUsage(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestUsage")
+	createTeam(t, tn.Id, "Team One")
+	createTeam(t, tn.Id, "Team Two")
+
+	// When
+
+	res, actual := sendApiReq[tenant.TenantUsageDTO](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/usage", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, tn.Id, actual.TenantId, "Tenant id is incorrect")
+	assert.Equal(t, int64(2), actual.Teams.Used, "Team usage is incorrect")
+	assert.Equal(t, tenant.DefaultQuotaPolicy.Tenant.MaxTeams, actual.Teams.Limit, "Team limit is incorrect")
+	assert.Equal(t, int64(1), actual.Members.Used, "Member usage is incorrect")
+	assert.Equal(t, int64(1), actual.ApiCallsToday.Used, "Api call usage is incorrect")
+	assert.Greater(t, actual.StorageBytes.Used, int64(0), "Storage usage is incorrect")
+}
+
+func TestNewTeam_QuotaExceeded(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestTeamQuota")
+	setTenantQuota(t, tn.Id, tenant.TenantQuota{MaxTeams: 1})
+	createTeam(t, tn.Id, "Team One")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/team",
+		&team.CreateNewTeamDTO{Name: "Team Two"},
+		ownerLogin.AccessToken,
+		tn.Id,
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "Team quota exceeded.", startTime, endTime)
+}
+
+func TestNewInvitation_MemberQuotaExceeded(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	invitee := createUser(t)
+	tn := createTenant(t, owner.Id, "TestMemberQuota")
+	setTenantQuota(t, tn.Id, tenant.TenantQuota{MaxMembers: 1})
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/invitations", tn.Id),
+		&tenant.CreateInvitationDTO{Username: invitee.Username, AccessLevel: auth.Viewer},
+		ownerLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "Member quota exceeded.", startTime, endTime)
+}
+
+func TestApiCallQuotaExceeded(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestApiCallQuota")
+	setTenantQuota(t, tn.Id, tenant.TenantQuota{MaxApiCallsPerDay: 1})
+
+	res, _ := sendApiReq[tenant.Tenant](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode, "Status code is not a 429")
+	assertApiError(t, actual, "Daily api call quota exceeded.", startTime, endTime)
+}
+
+func TestApiCallQuota_DeniedCallsAreNotCounted(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	viewer, viewerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestApiCallQuotaDenied")
+	createTenantUserAccess(t, tn.Id, viewer.Id, auth.Viewer)
+
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id),
+		nil,
+		viewerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+
+	res, actual := sendApiReq[tenant.TenantUsageDTO](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/usage", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, int64(1), actual.ApiCallsToday.Used, "Denied call was counted")
+}
+
+func TestUpdateQuota_NotSystemAdmin(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestUpdateQuota")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPut,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/quota", tn.Id),
+		&tenant.TenantQuota{MaxTeams: 1000},
+		ownerLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "User is not a system administrator.", startTime, endTime)
+}
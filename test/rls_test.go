@@ -0,0 +1,246 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ccthomas/gridiron/internal/tenant"
+	"github.com/ccthomas/gridiron/pkg/auth"
+	"github.com/ccthomas/gridiron/pkg/database"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowLevelSecurity_UnfilteredSelect(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	owner := createUser(t)
+	tenantA := createTenant(t, owner.Id, "TestRlsA")
+	tenantB := createTenant(t, owner.Id, "TestRlsB")
+	createTeam(t, tenantA.Id, "Team A")
+	createTeam(t, tenantB.Id, "Team B")
+
+	tx, err := database.BeginTenantTx(db, tenantA.Id)
+	if err != nil {
+		t.Fatal("Failed to begin tenant transaction.", err.Error())
+	}
+	defer tx.Rollback()
+
+	// When
+
+	rows, err := tx.Query("SELECT tenant_id FROM team.team")
+	if err != nil {
+		t.Fatal("Failed to select teams.", err.Error())
+	}
+	defer rows.Close()
+
+	// Then
+
+	count := 0
+	for rows.Next() {
+		var tenantId string
+		if err := rows.Scan(&tenantId); err != nil {
+			t.Fatal("Failed to scan row.", err.Error())
+		}
+
+		assert.Equal(t, tenantA.Id, tenantId, "Row of another tenant leaked.")
+		count++
+	}
+
+	assert.Equal(t, 1, count, "Tenant's own team is not visible.")
+}
+
+func TestRowLevelSecurity_InsertIntoOtherTenant(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	owner := createUser(t)
+	tenantA := createTenant(t, owner.Id, "TestRlsA")
+	tenantB := createTenant(t, owner.Id, "TestRlsB")
+
+	tx, err := database.BeginTenantTx(db, tenantA.Id)
+	if err != nil {
+		t.Fatal("Failed to begin tenant transaction.", err.Error())
+	}
+	defer tx.Rollback()
+
+	// When
+
+	_, err = tx.Exec("INSERT INTO team.team (id, tenant_id, name) VALUES ($1, $2, $3)", uuid.New().String(), tenantB.Id, "Team B")
+
+	// Then
+
+	assert.Error(t, err, "Insert into another tenant was allowed.")
+}
+
+func TestRowLevelSecurity_NoCurrentTenant(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	owner := createUser(t)
+	tn := createTenant(t, owner.Id, "TestRls")
+	createTeam(t, tn.Id, "Team A")
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal("Failed to begin transaction.", err.Error())
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SET LOCAL ROLE " + database.TenantRole)
+	if err != nil {
+		t.Fatal("Failed to set tenant role.", err.Error())
+	}
+
+	// When
+
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM team.team").Scan(&count)
+
+	// Then
+
+	assert.NoError(t, err, "Failed to count teams.")
+	assert.Equal(t, 0, count, "Rows are visible without a current tenant.")
+}
+
+func TestRowLevelSecurity_RoleResetAfterTransaction(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	owner := createUser(t)
+	tn := createTenant(t, owner.Id, "TestRls")
+
+	tx, err := database.BeginTenantTx(db, tn.Id)
+	if err != nil {
+		t.Fatal("Failed to begin tenant transaction.", err.Error())
+	}
+
+	// When
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal("Failed to commit tenant transaction.", err.Error())
+	}
+
+	var role string
+	var current string
+	err = db.QueryRow("SELECT current_user, COALESCE(current_setting($1, true), '')", database.TenantSetting).Scan(&role, &current)
+
+	// Then
+
+	assert.NoError(t, err, "Failed to read session state.")
+	assert.NotEqual(t, database.TenantRole, role, "Connection is still scoped to the tenant role.")
+	assert.Equal(t, "", current, "Connection is still scoped to the tenant.")
+}
+
+func TestRowLevelSecurity_UnfilteredMemberSelect(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	owner := createUser(t)
+	tenantA := createTenant(t, owner.Id, "TestRlsA")
+	createTenant(t, owner.Id, "TestRlsB")
+
+	tx, err := database.BeginTenantTx(db, tenantA.Id)
+	if err != nil {
+		t.Fatal("Failed to begin tenant transaction.", err.Error())
+	}
+	defer tx.Rollback()
+
+	// When
+
+	rows, err := tx.Query("SELECT tenant_id FROM tenant.tenant_user_access")
+	if err != nil {
+		t.Fatal("Failed to select tenant user access.", err.Error())
+	}
+	defer rows.Close()
+
+	// Then
+
+	count := 0
+	for rows.Next() {
+		var tenantId string
+		if err := rows.Scan(&tenantId); err != nil {
+			t.Fatal("Failed to scan row.", err.Error())
+		}
+
+		assert.Equal(t, tenantA.Id, tenantId, "Row of another tenant leaked.")
+		count++
+	}
+
+	assert.Equal(t, 1, count, "Tenant's own member is not visible.")
+}
+
+func TestRowLevelSecurity_RepositoryRevokeApiKeyOfOtherTenant(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	repo := &tenant.TenantRepositoryImpl{DB: db}
+
+	owner := createUser(t)
+	tenantA := createTenant(t, owner.Id, "TestRlsA")
+	tenantB := createTenant(t, owner.Id, "TestRlsB")
+
+	apiKey := tenant.ApiKey{
+		Id:        uuid.New().String(),
+		TenantId:  tenantB.Id,
+		Name:      "Test Key",
+		KeyPrefix: "grd_test",
+		KeyHash:   uuid.New().String(),
+		Scopes:    []auth.Permission{auth.TeamRead},
+		CreatedBy: owner.Id,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err := repo.InsertApiKey(apiKey)
+	if err != nil {
+		t.Fatal("Failed to insert api key.", err.Error())
+	}
+
+	// When
+
+	err = repo.RevokeApiKey(tenantA.Id, apiKey.Id)
+
+	// Then
+
+	assert.Error(t, err, "Api key of another tenant was revoked.")
+
+	apiKeys, err := repo.SelectApiKeysByTenant(tenantB.Id)
+	if err != nil {
+		t.Fatal("Failed to select api keys.", err.Error())
+	}
+
+	assert.Equal(t, 1, len(apiKeys), "Tenant's own api key is not visible.")
+	assert.Nil(t, apiKeys[0].RevokedAt, "Api key of another tenant was revoked.")
+}
+
+func TestRowLevelSecurity_RepositorySelectMembers(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	repo := &tenant.TenantRepositoryImpl{DB: db}
+
+	owner := createUser(t)
+	member := createUser(t)
+	tenantA := createTenant(t, owner.Id, "TestRlsA")
+	createTenant(t, member.Id, "TestRlsB")
+
+	// When
+
+	members, err := repo.SelectMembers(tenantA.Id)
+
+	// Then
+
+	assert.NoError(t, err, "Failed to select members in a tenant transaction.")
+	assert.Equal(t, 1, len(members), "Members of another tenant leaked.")
+	assert.Equal(t, owner.Id, members[0].UserAccountId, "Tenant's own member is not visible.")
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
@@ -8,7 +9,9 @@ import (
 
 	"github.com/ccthomas/gridiron/internal/team"
 	"github.com/ccthomas/gridiron/internal/tenant"
+	"github.com/ccthomas/gridiron/pkg/auth"
 	"github.com/ccthomas/gridiron/pkg/database"
+	"github.com/ccthomas/gridiron/pkg/league"
 	"github.com/ccthomas/gridiron/pkg/logger"
 	"github.com/ccthomas/gridiron/pkg/myhttp"
 	"github.com/google/uuid"
@@ -73,8 +76,8 @@ func TestNewTeam_NoTenantId(t *testing.T) {
 
 	// Then
 
-	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Status code is not a 401")
-	assertApiError(t, actual, "User is unauthorized to access tenant.", startTime, endTime)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "x-tenant-id header is missing.", startTime, endTime)
 }
 
 func TestNewTeam_DoesNotHaveAccessTenant(t *testing.T) {
@@ -102,14 +105,78 @@ func TestNewTeam_DoesNotHaveAccessTenant(t *testing.T) {
 
 	// Then
 
-	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
 	assertApiError(t, actual, "User is unauthorized to access tenant.", startTime, endTime)
 }
 
+func TestNewTeam_Editor(t *testing.T) {
+	// Given
+
+	owner := createUser(t)
+	u, loginRes := login(t)
+	tn := createTenant(t, owner.Id, "TestTenantName")
+	createTenantUserAccess(t, tn.Id, u.Id, auth.Editor)
+
+	dto := &team.CreateNewTeamDTO{
+		Name: fmt.Sprintf("TestTeamName%s", uuid.New().String()),
+	}
+
+	// When
+
+	res, actual := sendApiReq[team.Team](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/team",
+		dto,
+		loginRes.AccessToken,
+		tn.Id,
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	cleanUpTeam(t, actual.Id)
+
+	assert.Equal(t, dto.Name, actual.Name, "Actual name is incorrect.")
+}
+
+func TestNewTeam_ViewerForbidden(t *testing.T) {
+	// Given
+
+	owner := createUser(t)
+	u, loginRes := login(t)
+	tn := createTenant(t, owner.Id, "TestTenantName")
+	createTenantUserAccess(t, tn.Id, u.Id, auth.Viewer)
+
+	dto := &team.CreateNewTeamDTO{
+		Name: fmt.Sprintf("TestTeamName%s", uuid.New().String()),
+	}
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/team",
+		dto,
+		loginRes.AccessToken,
+		tn.Id,
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "User does not have permission to perform this action.", startTime, endTime)
+}
+
 func TestGetAllTeams_EmptyResponse(t *testing.T) {
 	// Given
 
-	_, loginRes := login(t)
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, fmt.Sprintf("TestTenant%s", u.Id))
 
 	// When
 
@@ -119,7 +186,7 @@ func TestGetAllTeams_EmptyResponse(t *testing.T) {
 		"http://localhost:8080/team",
 		nil,
 		loginRes.AccessToken,
-		"",
+		tn.Id,
 	)
 
 	// Then
@@ -156,6 +223,425 @@ func TestGetAllTeams_MultipleTeams(t *testing.T) {
 	assert.Equal(t, tm2, actual.Data[1], "data does not equal second team.")
 }
 
+func TestGetAllTeams_DoesNotHaveAccessTenant(t *testing.T) {
+	// Given
+
+	owner := createUser(t)
+	_, loginRes := login(t)
+	tn := createTenant(t, owner.Id, fmt.Sprintf("TestTenant%s", owner.Id))
+	createTeam(t, tn.Id, fmt.Sprintf("TestTeamA%s", tn.Id))
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/team",
+		nil,
+		loginRes.AccessToken,
+		tn.Id,
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "User is unauthorized to access tenant.", startTime, endTime)
+}
+
+func TestGetAllTeams_ForgedRequestContextIgnored(t *testing.T) {
+	// Given
+
+	owner := createUser(t)
+	attacker, loginRes := login(t)
+	tn := createTenant(t, owner.Id, fmt.Sprintf("TestTenant%s", owner.Id))
+
+	forged, err := json.Marshal(auth.AuthorizerContext{
+		UserId:       attacker.Id,
+		TenantAccess: map[string]auth.AccessLevel{tn.Id: auth.Owner},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:8080/team", nil)
+	if err != nil {
+		t.Fatal("Failed to construct request", err.Error())
+	}
+
+	req.Header.Set("Authorization", loginRes.AccessToken)
+	req.Header.Set("x-tenant-id", tn.Id)
+	req.Header.Set("Request-Context", string(forged))
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Api request failed.", err.Error())
+	}
+	endTime := time.Now().UTC()
+
+	// Then
+
+	var actual myhttp.ApiError
+	err = json.NewDecoder(res.Body).Decode(&actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "User is unauthorized to access tenant.", startTime, endTime)
+}
+
+func TestNewTeam_Metadata(t *testing.T) {
+	// Given
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, "TestNewTeam")
+
+	foundedYear := 1998
+	active := false
+	dto := &team.CreateNewTeamDTO{
+		Name:           "Gridiron Geese",
+		Abbreviation:   "ggs",
+		Location:       "Gridiron",
+		Nickname:       "Geese",
+		PrimaryColor:   "#112233",
+		SecondaryColor: "#FFFFFF",
+		Venue:          "Goose Pond",
+		FoundedYear:    &foundedYear,
+		Active:         &active,
+	}
+
+	// When
+
+	res, actual := sendApiReq[team.Team](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/team",
+		dto,
+		loginRes.AccessToken,
+		tn.Id,
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	cleanUpTeam(t, actual.Id)
+
+	assert.Equal(t, "GGS", actual.Abbreviation, "abbreviation was not upper cased")
+	assert.Equal(t, dto.Location, actual.Location, "location is incorrect")
+	assert.Equal(t, dto.Nickname, actual.Nickname, "nickname is incorrect")
+	assert.Equal(t, dto.PrimaryColor, actual.PrimaryColor, "primary color is incorrect")
+	assert.Equal(t, dto.SecondaryColor, actual.SecondaryColor, "secondary color is incorrect")
+	assert.Equal(t, dto.Venue, actual.Venue, "venue is incorrect")
+	assert.Equal(t, foundedYear, *actual.FoundedYear, "founding year is incorrect")
+	assert.False(t, actual.Active, "team is active")
+
+	_, stored := sendApiReq[team.Team](t, http.MethodGet, fmt.Sprintf("http://localhost:8080/team/%s", actual.Id), nil, loginRes.AccessToken, tn.Id)
+	assert.Equal(t, actual, stored, "stored team is incorrect")
+}
+
+func TestNewTeam_DuplicateAbbreviation(t *testing.T) {
+	// Given
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, "TestNewTeam")
+
+	res, existing := sendApiReq[team.Team](t, http.MethodPost, "http://localhost:8080/team", &team.CreateNewTeamDTO{Name: "First", Abbreviation: "DUP"}, loginRes.AccessToken, tn.Id)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	cleanUpTeam(t, existing.Id)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/team",
+		&team.CreateNewTeamDTO{Name: "Second", Abbreviation: "dup"},
+		loginRes.AccessToken,
+		tn.Id,
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode, "Status code is not a 409")
+	assertApiError(t, actual, "Abbreviation is already used by another team.", startTime, endTime)
+}
+
+func TestNewTeam_InvalidMetadata(t *testing.T) {
+	tooEarly := 1700
+	tests := []struct {
+		name    string
+		dto     team.CreateNewTeamDTO
+		message string
+	}{
+		{"empty name", team.CreateNewTeamDTO{Name: " "}, "Name must be between 1 and 255 characters."},
+		{"abbreviation", team.CreateNewTeamDTO{Name: "Team", Abbreviation: "TOOLONG"}, "Abbreviation must be 1 to 5 letters or digits."},
+		{"color", team.CreateNewTeamDTO{Name: "Team", PrimaryColor: "red"}, "Colors must be hex codes such as #A71930."},
+		{"founding year", team.CreateNewTeamDTO{Name: "Team", FoundedYear: &tooEarly}, fmt.Sprintf("Founding year must be between 1800 and %d.", time.Now().Year())},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Given
+			u, loginRes := login(t)
+			tn := createTenant(t, u.Id, "TestNewTeam")
+
+			// When
+
+			startTime := time.Now().UTC()
+			res, actual := sendApiReq[myhttp.ApiError](
+				t,
+				http.MethodPost,
+				"http://localhost:8080/team",
+				&test.dto,
+				loginRes.AccessToken,
+				tn.Id,
+			)
+			endTime := time.Now().UTC()
+
+			// Then
+
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+			assertApiError(t, actual, test.message, startTime, endTime)
+		})
+	}
+}
+
+func TestGetTeam(t *testing.T) {
+	// Given
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, "TestGetTeam")
+	tm := createTeam(t, tn.Id, "TestGetTeam")
+
+	// When
+
+	res, actual := sendApiReq[team.Team](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/team/%s", tm.Id),
+		nil,
+		loginRes.AccessToken,
+		tn.Id,
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, tm.Id, actual.Id, "team id is incorrect")
+	assert.Equal(t, tm.Name, actual.Name, "team name is incorrect")
+	assert.Equal(t, 1, actual.Version, "team version is incorrect")
+}
+
+func TestGetTeam_OtherTenant(t *testing.T) {
+	// Given
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, "TestGetTeam")
+	other := createTenant(t, createUser(t).Id, "TestGetTeam")
+	tm := createTeam(t, other.Id, "TestGetTeam")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/team/%s", tm.Id),
+		nil,
+		loginRes.AccessToken,
+		tn.Id,
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "Status code is not a 404")
+	assertApiError(t, actual, "Team not found.", startTime, endTime)
+}
+
+func TestUpdateTeam(t *testing.T) {
+	// Given
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, "TestUpdateTeam")
+	tm := createTeam(t, tn.Id, "TestUpdateTeam")
+
+	name := "TestUpdateTeam Renamed"
+
+	// When
+
+	res, actual := sendApiReq[team.Team](
+		t,
+		http.MethodPatch,
+		fmt.Sprintf("http://localhost:8080/team/%s", tm.Id),
+		&team.UpdateTeamDTO{Name: &name, Version: 1},
+		loginRes.AccessToken,
+		tn.Id,
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, name, actual.Name, "team name is incorrect")
+	assert.Equal(t, 2, actual.Version, "team version was not incremented")
+
+	_, stored := sendApiReq[team.Team](t, http.MethodGet, fmt.Sprintf("http://localhost:8080/team/%s", tm.Id), nil, loginRes.AccessToken, tn.Id)
+	assert.Equal(t, name, stored.Name, "stored team name is incorrect")
+}
+
+func TestUpdateTeam_Metadata(t *testing.T) {
+	// Given
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, "TestUpdateTeam")
+
+	foundedYear := 1998
+	res, tm := sendApiReq[team.Team](t, http.MethodPost, "http://localhost:8080/team", &team.CreateNewTeamDTO{Name: "TestUpdateTeam", Abbreviation: "TUT", FoundedYear: &foundedYear}, loginRes.AccessToken, tn.Id)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	cleanUpTeam(t, tm.Id)
+
+	venue := "New Stadium"
+	clearYear := 0
+	active := false
+
+	// When
+
+	res, actual := sendApiReq[team.Team](
+		t,
+		http.MethodPatch,
+		fmt.Sprintf("http://localhost:8080/team/%s", tm.Id),
+		&team.UpdateTeamDTO{Venue: &venue, FoundedYear: &clearYear, Active: &active, Version: tm.Version},
+		loginRes.AccessToken,
+		tn.Id,
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, "TestUpdateTeam", actual.Name, "name was changed")
+	assert.Equal(t, "TUT", actual.Abbreviation, "abbreviation was changed")
+	assert.Equal(t, venue, actual.Venue, "venue is incorrect")
+	assert.Nil(t, actual.FoundedYear, "founding year was not cleared")
+	assert.False(t, actual.Active, "team is still active")
+}
+
+func TestUpdateTeam_VersionConflict(t *testing.T) {
+	// Given
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, "TestUpdateTeam")
+	tm := createTeam(t, tn.Id, "TestUpdateTeam")
+
+	first := "TestUpdateTeam First"
+	second := "TestUpdateTeam Second"
+	res, _ := sendApiReq[team.Team](t, http.MethodPatch, fmt.Sprintf("http://localhost:8080/team/%s", tm.Id), &team.UpdateTeamDTO{Name: &first, Version: 1}, loginRes.AccessToken, tn.Id)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPatch,
+		fmt.Sprintf("http://localhost:8080/team/%s", tm.Id),
+		&team.UpdateTeamDTO{Name: &second, Version: 1},
+		loginRes.AccessToken,
+		tn.Id,
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode, "Status code is not a 409")
+	assertApiError(t, actual, "Team version is out of date.", startTime, endTime)
+}
+
+func TestUpdateTeam_InvalidName(t *testing.T) {
+	// Given
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, "TestUpdateTeam")
+	tm := createTeam(t, tn.Id, "TestUpdateTeam")
+
+	name := " "
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPatch,
+		fmt.Sprintf("http://localhost:8080/team/%s", tm.Id),
+		&team.UpdateTeamDTO{Name: &name, Version: 1},
+		loginRes.AccessToken,
+		tn.Id,
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "Name must be between 1 and 255 characters.", startTime, endTime)
+}
+
+func TestDeleteTeam(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	u, loginRes := login(t)
+	tn := createTenant(t, u.Id, "TestDeleteTeam")
+	tm := createTeam(t, tn.Id, "TestDeleteTeam")
+
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/team/%s", tm.Id),
+		nil,
+		loginRes.AccessToken,
+		tn.Id,
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM team.team WHERE id = $1", tm.Id).Scan(&count)
+	if err != nil {
+		t.Fatal("Failed to count teams.", err.Error())
+	}
+
+	assert.Equal(t, 0, count, "team was not deleted")
+}
+
+func TestDeleteTeam_ViewerForbidden(t *testing.T) {
+	// Given
+	owner := createUser(t)
+	viewer, viewerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestDeleteTeam")
+	createTenantUserAccess(t, tn.Id, viewer.Id, auth.Viewer)
+	tm := createTeam(t, tn.Id, "TestDeleteTeam")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/team/%s", tm.Id),
+		nil,
+		viewerLogin.AccessToken,
+		tn.Id,
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "User does not have permission to perform this action.", startTime, endTime)
+}
+
 func TestProcessNewTenantMessage(t *testing.T) {
 	// Given
 
@@ -204,3 +690,167 @@ func TestProcessNewTenantMessage(t *testing.T) {
 
 	assert.Equal(t, 32, len(teams), "Teams is not of length 32.")
 }
+
+func TestProcessNewTenantMessage_LeagueTemplates(t *testing.T) {
+	custom := &league.Template{
+		Name:  "Backyard League",
+		Teams: []league.TeamDefinition{{Name: "Sharks"}, {Name: "Jets"}},
+	}
+
+	cases := []struct {
+		name     string
+		dto      tenant.CreateTenantDTO
+		expected []string
+	}{
+		{name: "cfl", dto: tenant.CreateTenantDTO{LeagueTemplate: league.Cfl}, expected: nil},
+		{name: "empty", dto: tenant.CreateTenantDTO{LeagueTemplate: league.Empty}, expected: []string{}},
+		{name: "custom", dto: tenant.CreateTenantDTO{LeagueTemplate: league.Custom, CustomLeague: custom}, expected: []string{"Jets", "Sharks"}},
+	}
+
+	cfl, err := league.Lookup(league.Cfl)
+	if err != nil {
+		t.Fatal("Failed to look up cfl template.", err.Error())
+	}
+	for _, definition := range cfl.Teams {
+		cases[0].expected = append(cases[0].expected, definition.Name)
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+
+			_, loginRes := login(t)
+
+			// When
+
+			res, actual := sendApiReq[*tenant.Tenant](
+				t,
+				http.MethodPost,
+				fmt.Sprintf("http://localhost:8080/tenant/%s", uuid.New().String()),
+				tc.dto,
+				loginRes.AccessToken,
+				"",
+			)
+
+			// Then
+
+			assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+			cleanUpTenant(t, actual.Id)
+			time.Sleep(6 * time.Second)
+
+			teams := selectTeamsByTenant(t, actual.Id)
+			names := []string{}
+			for _, team := range teams {
+				names = append(names, team.Name)
+			}
+
+			assert.ElementsMatch(t, tc.expected, names, "Seeded teams do not match the league template.")
+		})
+	}
+}
+
+func TestProcessNewTenantMessage_SeedsTeamMetadata(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+
+	// When
+
+	res, actual := sendApiReq[tenant.Tenant](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", uuid.New().String()),
+		nil,
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	cleanUpTenant(t, actual.Id)
+	time.Sleep(6 * time.Second)
+
+	var bills *team.Team
+	teams := selectTeamsByTenant(t, actual.Id)
+	for i := range teams {
+		if teams[i].Name == "Buffalo Bills" {
+			bills = &teams[i]
+		}
+	}
+
+	if bills == nil {
+		t.Fatal("Buffalo Bills were not seeded.")
+	}
+
+	assert.Equal(t, "BUF", bills.Abbreviation, "abbreviation is incorrect")
+	assert.Equal(t, "Buffalo", bills.Location, "location is incorrect")
+	assert.Equal(t, "Bills", bills.Nickname, "nickname is incorrect")
+	assert.Equal(t, "#00338D", bills.PrimaryColor, "primary color is incorrect")
+	assert.Equal(t, "#C60C30", bills.SecondaryColor, "secondary color is incorrect")
+	assert.Equal(t, "Highmark Stadium", bills.Venue, "venue is incorrect")
+	assert.Equal(t, 1960, *bills.FoundedYear, "founding year is incorrect")
+	assert.True(t, bills.Active, "team is not active")
+}
+
+func selectTeamsByTenant(t *testing.T, tenantId string) []team.Team {
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, tenant_id, name, COALESCE(abbreviation, ''), location, nickname, primary_color, secondary_color, venue, founded_year, active FROM team.team WHERE tenant_id = $1", tenantId)
+	if err != nil {
+		t.Fatal("Failed to prepare query.", err.Error())
+	}
+	defer rows.Close()
+
+	var teams []team.Team
+	for rows.Next() {
+		var team team.Team
+		if err := rows.Scan(&team.Id, &team.TenantId, &team.Name, &team.Abbreviation, &team.Location, &team.Nickname, &team.PrimaryColor, &team.SecondaryColor, &team.Venue, &team.FoundedYear, &team.Active); err != nil {
+			t.Fatal("Failed to scan row.")
+		}
+
+		teams = append(teams, team)
+	}
+
+	return teams
+}
+
+func TestGetLeagueTemplates(t *testing.T) {
+	// Given
+
+	_, loginRes := login(t)
+
+	// When
+
+	res, actual := sendApiReq[league.TemplateGetAllDTO](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/league-templates",
+		nil,
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, len(actual.Data), actual.Count, "Count does not match data.")
+
+	byName := map[string]league.Template{}
+	for _, template := range actual.Data {
+		byName[template.Name] = template
+	}
+
+	for _, name := range []string{league.Nfl, league.NcaaFbs, league.Cfl, league.Empty} {
+		assert.Contains(t, byName, name, "Built in league template is missing.")
+	}
+
+	nfl := byName[league.Nfl]
+	assert.Equal(t, 32, len(nfl.Teams), "Nfl template does not have 32 teams.")
+	for _, definition := range nfl.Teams {
+		assert.NotEmpty(t, definition.Abbreviation, "Nfl team is missing an abbreviation.")
+		assert.NotEmpty(t, definition.Division, "Nfl team is missing a division.")
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/ccthomas/gridiron/internal/team"
 	"github.com/ccthomas/gridiron/internal/tenant"
 	"github.com/ccthomas/gridiron/pkg/auth"
 	"github.com/ccthomas/gridiron/pkg/database"
+	"github.com/ccthomas/gridiron/pkg/league"
+	"github.com/ccthomas/gridiron/pkg/myhttp"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -34,7 +38,7 @@ func TestNewTenant(t *testing.T) {
 
 	cleanUpTenant(t, actual.Id)
 
-	rows, err := db.Query("SELECT * FROM tenant.tenant WHERE id = $1", actual.Id)
+	rows, err := db.Query("SELECT id, name FROM tenant.tenant WHERE id = $1", actual.Id)
 	if err != nil {
 		t.Fatal("Failed to prepare query.", err.Error())
 	}
@@ -70,6 +74,56 @@ func TestNewTenant(t *testing.T) {
 	assert.Equal(t, userAccess.AccessLevel, auth.Owner, "User access access level is incorrect")
 }
 
+func TestNewTenant_UnknownLeagueTemplate(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", uuid.New().String()),
+		&tenant.CreateTenantDTO{LeagueTemplate: "xfl"},
+		loginRes.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "League template is unknown.", startTime, endTime)
+}
+
+func TestNewTenant_InvalidCustomLeague(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+	custom := &league.Template{
+		Name:  "Backyard League",
+		Teams: []league.TeamDefinition{{Name: "Sharks"}, {Name: "sharks"}},
+	}
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", uuid.New().String()),
+		&tenant.CreateTenantDTO{LeagueTemplate: league.Custom, CustomLeague: custom},
+		loginRes.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "Custom league is invalid.", startTime, endTime)
+}
+
 func TestGetAllTenants(t *testing.T) {
 	// Given
 
@@ -99,3 +153,701 @@ func TestGetAllTenants(t *testing.T) {
 	assert.Equal(t, actual.Data[0], tenant1, "data does not equal first tenant.")
 	assert.Equal(t, actual.Data[1], tenant2, "data does not equal second tenant.")
 }
+
+func TestInvitation_Accept(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	invitee, inviteeLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestInvitation")
+
+	res, invitation := sendApiReq[tenant.TenantInvitation](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/invitations", tn.Id),
+		&tenant.CreateInvitationDTO{Username: invitee.Username, AccessLevel: auth.Editor},
+		ownerLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	res, pending := sendApiReq[tenant.TenantInvitationGetAllDTO](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/tenant/invitations",
+		nil,
+		inviteeLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, 1, pending.Count, "count does not equal 1.")
+	assert.Equal(t, invitation.Id, pending.Data[0].Id, "pending invitation is incorrect.")
+
+	// When
+
+	res = sendApiReqNoContent(
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/invitations/%s/accept", invitation.Id),
+		nil,
+		inviteeLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	res, members := sendApiReq[tenant.TenantMemberGetAllDTO](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/members", tn.Id),
+		nil,
+		inviteeLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, 2, members.Count, "count does not equal 2.")
+
+	accessLevels := map[string]auth.AccessLevel{}
+	for _, member := range members.Data {
+		accessLevels[member.UserAccountId] = member.AccessLevel
+	}
+	assert.Equal(t, auth.Owner, accessLevels[owner.Id], "owner access level is incorrect.")
+	assert.Equal(t, auth.Editor, accessLevels[invitee.Id], "invitee access level is incorrect.")
+}
+
+func TestInvitation_AcceptByOtherUser(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	invitee := createUser(t)
+	_, otherLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestInvitation")
+
+	_, invitation := sendApiReq[tenant.TenantInvitation](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/invitations", tn.Id),
+		&tenant.CreateInvitationDTO{Username: invitee.Username, AccessLevel: auth.Viewer},
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/invitations/%s/accept", invitation.Id),
+		nil,
+		otherLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "Status code is not a 404")
+	assertApiError(t, actual, "Invitation not found.", startTime, endTime)
+}
+
+func TestUpdateMember(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	member := createUser(t)
+	tn := createTenant(t, owner.Id, "TestMembers")
+	createTenantUserAccess(t, tn.Id, member.Id, auth.Viewer)
+
+	// When
+
+	res, actual := sendApiReq[tenant.TenantUserAccess](
+		t,
+		http.MethodPatch,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/members/%s", tn.Id, member.Id),
+		&tenant.UpdateMemberDTO{AccessLevel: auth.Scorer},
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, auth.Scorer, actual.AccessLevel, "access level is incorrect.")
+}
+
+func TestRemoveMember_Owner(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestMembers")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/members/%s", tn.Id, owner.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "Owner access cannot be changed.", startTime, endTime)
+}
+
+func TestRemoveMember_ViewerForbidden(t *testing.T) {
+	// Given
+	owner := createUser(t)
+	viewer, viewerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestMembers")
+	createTenantUserAccess(t, tn.Id, viewer.Id, auth.Viewer)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/members/%s", tn.Id, viewer.Id),
+		nil,
+		viewerLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "User does not have permission to perform this action.", startTime, endTime)
+}
+
+func TestRemoveMember_AdminCannotRemoveAdmin(t *testing.T) {
+	// Given
+	owner := createUser(t)
+	admin, adminLogin := login(t)
+	otherAdmin := createUser(t)
+	tn := createTenant(t, owner.Id, "TestMembers")
+	createTenantUserAccess(t, tn.Id, admin.Id, auth.Admin)
+	createTenantUserAccess(t, tn.Id, otherAdmin.Id, auth.Admin)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/members/%s", tn.Id, otherAdmin.Id),
+		nil,
+		adminLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "Members at or above your access level cannot be changed.", startTime, endTime)
+}
+
+func TestApiKey_ScopedAccess(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestApiKey")
+
+	res, created := sendApiReq[tenant.ApiKeyCreatedDTO](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/api-keys", tn.Id),
+		&tenant.CreateApiKeyDTO{Name: "ingest", Scopes: []auth.Permission{auth.TeamRead}},
+		ownerLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.NotEmpty(t, created.Key, "Api key was not returned")
+
+	// When
+
+	readRes := sendApiKeyReq(t, http.MethodGet, "http://localhost:8080/team", nil, created.Key, tn.Id)
+	writeRes := sendApiKeyReq(t, http.MethodPost, "http://localhost:8080/team", &team.CreateNewTeamDTO{Name: "Scoped"}, created.Key, tn.Id)
+	userRes := sendApiKeyReq(t, http.MethodGet, "http://localhost:8080/tenant", nil, created.Key, "")
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, readRes.StatusCode, "Api key with team:read cannot read teams")
+	assert.Equal(t, http.StatusForbidden, writeRes.StatusCode, "Api key without team:write can create teams")
+	assert.Equal(t, http.StatusUnauthorized, userRes.StatusCode, "Api key was accepted on a user route")
+}
+
+func TestApiKey_Revoked(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestApiKey")
+
+	_, created := sendApiReq[tenant.ApiKeyCreatedDTO](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/api-keys", tn.Id),
+		&tenant.CreateApiKeyDTO{Name: "ingest", Scopes: []auth.Permission{auth.TeamRead}},
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/api-keys/%s", tn.Id, created.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	// When
+
+	res = sendApiKeyReq(t, http.MethodGet, "http://localhost:8080/team", nil, created.Key, tn.Id)
+
+	// Then
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Revoked api key was accepted")
+}
+
+func TestGetTenant(t *testing.T) {
+	// Given
+	owner := createUser(t)
+	viewer, viewerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestGetTenant")
+	createTenantUserAccess(t, tn.Id, viewer.Id, auth.Viewer)
+
+	// When
+
+	res, actual := sendApiReq[tenant.Tenant](
+		t,
+		http.MethodGet,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id),
+		nil,
+		viewerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, tn.Id, actual.Id, "tenant id is incorrect")
+	assert.Equal(t, tn.Name, actual.Name, "tenant name is incorrect")
+}
+
+func TestUpdateTenant(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestUpdateTenant")
+
+	// When
+
+	res, actual := sendApiReq[tenant.Tenant](
+		t,
+		http.MethodPatch,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id),
+		&tenant.UpdateTenantDTO{Name: "  Renamed League  "},
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, "Renamed League", actual.Name, "tenant name is incorrect")
+
+	var name string
+	err := db.QueryRow("SELECT name FROM tenant.tenant WHERE id = $1", tn.Id).Scan(&name)
+	if err != nil {
+		t.Fatal("Failed to select tenant.", err.Error())
+	}
+
+	assert.Equal(t, "Renamed League", name, "tenant was not renamed")
+}
+
+func TestUpdateTenant_BlankName(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestUpdateTenant")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPatch,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id),
+		&tenant.UpdateTenantDTO{Name: " "},
+		ownerLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "Name must be between 1 and 255 characters.", startTime, endTime)
+}
+
+func TestDeleteTenant(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	owner, ownerLogin := login(t)
+	member := createUser(t)
+	tn := createTenant(t, owner.Id, "TestDeleteTenant")
+	createTenantUserAccess(t, tn.Id, member.Id, auth.Editor)
+	createTeam(t, tn.Id, "TestDeleteTenant")
+
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	var deletedAt *time.Time
+	err := db.QueryRow("SELECT deleted_at FROM tenant.tenant WHERE id = $1", tn.Id).Scan(&deletedAt)
+	if err != nil {
+		t.Fatal("Failed to select tenant.", err.Error())
+	}
+
+	assert.NotNil(t, deletedAt, "tenant is not marked deleted")
+
+	for _, query := range []string{
+		"SELECT COUNT(*) FROM tenant.tenant_user_access WHERE tenant_id = $1",
+		"SELECT COUNT(*) FROM team.team WHERE tenant_id = $1",
+	} {
+		var count int
+		err := db.QueryRow(query, tn.Id).Scan(&count)
+		if err != nil {
+			t.Fatal("Failed to count rows.", err.Error())
+		}
+
+		assert.Equal(t, 1, count, "rows were not retained after delete: %s", query)
+	}
+
+	_, tenants := sendApiReq[tenant.TenantGetAllDTO](t, http.MethodGet, "http://localhost:8080/tenant", nil, ownerLogin.AccessToken, "")
+	assert.Equal(t, 0, tenants.Count, "deleted tenant is still listed")
+
+	res, _ = sendApiReq[myhttp.ApiError](t, http.MethodGet, fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id), nil, ownerLogin.AccessToken, "")
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+}
+
+func TestRestoreTenant(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestRestoreTenant")
+	createTeam(t, tn.Id, "TestRestoreTenant")
+
+	res := sendApiReqNoContent(t, http.MethodDelete, fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id), nil, ownerLogin.AccessToken, "")
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	// When
+
+	res, actual := sendApiReq[tenant.Tenant](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/restore", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, tn.Id, actual.Id, "tenant id is incorrect")
+	assert.Nil(t, actual.DeletedAt, "tenant is still marked deleted")
+
+	_, tenants := sendApiReq[tenant.TenantGetAllDTO](t, http.MethodGet, "http://localhost:8080/tenant", nil, ownerLogin.AccessToken, "")
+	assert.Equal(t, 1, tenants.Count, "restored tenant is not listed")
+
+	_, teams := sendApiReq[team.TeamGetAllDTO](t, http.MethodGet, "http://localhost:8080/team", nil, ownerLogin.AccessToken, tn.Id)
+	assert.Equal(t, 1, teams.Count, "team was not retained")
+}
+
+func TestRestoreTenant_NotDeleted(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestRestoreTenant")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/restore", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "Status code is not a 404")
+	assertApiError(t, actual, "Tenant not found.", startTime, endTime)
+}
+
+func TestRestoreTenant_RetentionExpired(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	owner, ownerLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestRestoreTenant")
+
+	_, err := db.Exec("UPDATE tenant.tenant SET deleted_at = NOW() - INTERVAL '365 days' WHERE id = $1", tn.Id)
+	if err != nil {
+		t.Fatal("Failed to backdate tenant deletion.", err.Error())
+	}
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/restore", tn.Id),
+		nil,
+		ownerLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "Status code is not a 404")
+	assertApiError(t, actual, "Tenant not found.", startTime, endTime)
+}
+
+func TestPurgeDeletedTenants(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	repo := &tenant.TenantRepositoryImpl{DB: db}
+	owner := createUser(t)
+	expired := createTenant(t, owner.Id, "TestPurgeDeletedTenants")
+	retained := createTenant(t, owner.Id, "TestPurgeDeletedTenants")
+	createTeam(t, expired.Id, "TestPurgeDeletedTenants")
+
+	_, err := db.Exec("UPDATE tenant.tenant SET deleted_at = NOW() - INTERVAL '365 days' WHERE id = $1", expired.Id)
+	if err != nil {
+		t.Fatal("Failed to backdate tenant deletion.", err.Error())
+	}
+
+	_, err = db.Exec("UPDATE tenant.tenant SET deleted_at = NOW() WHERE id = $1", retained.Id)
+	if err != nil {
+		t.Fatal("Failed to delete tenant.", err.Error())
+	}
+
+	// When
+
+	purged, err := repo.PurgeDeletedTenants(time.Now().Add(-tenant.DefaultRetentionPolicy.Retention))
+
+	// Then
+
+	assert.Nil(t, err, "purge failed")
+
+	ids := []string{}
+	for _, p := range purged {
+		ids = append(ids, p.Id)
+	}
+
+	assert.Contains(t, ids, expired.Id, "expired tenant was not purged")
+	assert.NotContains(t, ids, retained.Id, "retained tenant was purged")
+
+	for _, query := range []string{
+		"SELECT COUNT(*) FROM tenant.tenant WHERE id = $1",
+		"SELECT COUNT(*) FROM tenant.tenant_user_access WHERE tenant_id = $1",
+		"SELECT COUNT(*) FROM team.team WHERE tenant_id = $1",
+	} {
+		var count int
+		err := db.QueryRow(query, expired.Id).Scan(&count)
+		if err != nil {
+			t.Fatal("Failed to count rows.", err.Error())
+		}
+
+		assert.Equal(t, 0, count, "rows remain after purge: %s", query)
+	}
+}
+
+func TestDeleteTenant_AdminForbidden(t *testing.T) {
+	// Given
+	owner := createUser(t)
+	admin, adminLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestDeleteTenant")
+	createTenantUserAccess(t, tn.Id, admin.Id, auth.Admin)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodDelete,
+		fmt.Sprintf("http://localhost:8080/tenant/%s", tn.Id),
+		nil,
+		adminLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "User does not have permission to perform this action.", startTime, endTime)
+}
+
+func newOwnershipTransfer(t *testing.T, tenantId string, userId string, accessToken string) tenant.OwnershipTransfer {
+	res, transfer := sendApiReq[tenant.OwnershipTransfer](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/transfer-ownership", tenantId),
+		&tenant.CreateOwnershipTransferDTO{UserAccountId: userId},
+		accessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	return transfer
+}
+
+func TestOwnershipTransfer_Accept(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	member, memberLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestOwnershipTransfer")
+	createTenantUserAccess(t, tn.Id, member.Id, auth.Editor)
+
+	transfer := newOwnershipTransfer(t, tn.Id, member.Id, ownerLogin.AccessToken)
+	assert.Equal(t, tenant.TransferPending, transfer.Status, "status is incorrect")
+
+	_, pending := sendApiReq[tenant.OwnershipTransferGetAllDTO](t, http.MethodGet, "http://localhost:8080/tenant/ownership-transfers", nil, memberLogin.AccessToken, "")
+	assert.Equal(t, 1, pending.Count, "pending transfer count is incorrect")
+
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/ownership-transfers/%s/accept", transfer.Id),
+		nil,
+		memberLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	_, memberCtx := sendApiReq[auth.AuthorizerContext](t, http.MethodGet, "http://localhost:8080/user/authorizer-context", nil, memberLogin.AccessToken, "")
+	_, ownerCtx := sendApiReq[auth.AuthorizerContext](t, http.MethodGet, "http://localhost:8080/user/authorizer-context", nil, ownerLogin.AccessToken, "")
+
+	assert.Equal(t, auth.Owner, memberCtx.TenantAccess[tn.Id], "Recipient is not an owner")
+	assert.Equal(t, auth.Admin, ownerCtx.TenantAccess[tn.Id], "Previous owner was not demoted to admin")
+
+	res = sendApiReqNoContent(
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/ownership-transfers/%s/accept", transfer.Id),
+		nil,
+		memberLogin.AccessToken,
+		"",
+	)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Transfer was accepted twice")
+}
+
+func TestOwnershipTransfer_Decline(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	member, memberLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestOwnershipTransfer")
+	createTenantUserAccess(t, tn.Id, member.Id, auth.Viewer)
+
+	transfer := newOwnershipTransfer(t, tn.Id, member.Id, ownerLogin.AccessToken)
+
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/ownership-transfers/%s/decline", transfer.Id),
+		nil,
+		memberLogin.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	_, ownerCtx := sendApiReq[auth.AuthorizerContext](t, http.MethodGet, "http://localhost:8080/user/authorizer-context", nil, ownerLogin.AccessToken, "")
+	assert.Equal(t, auth.Owner, ownerCtx.TenantAccess[tn.Id], "Owner lost ownership after decline")
+}
+
+func TestOwnershipTransfer_AcceptByOtherUser(t *testing.T) {
+	// Given
+	owner, ownerLogin := login(t)
+	member := createUser(t)
+	other, otherLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestOwnershipTransfer")
+	createTenantUserAccess(t, tn.Id, member.Id, auth.Admin)
+	createTenantUserAccess(t, tn.Id, other.Id, auth.Admin)
+
+	transfer := newOwnershipTransfer(t, tn.Id, member.Id, ownerLogin.AccessToken)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/ownership-transfers/%s/accept", transfer.Id),
+		nil,
+		otherLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "Status code is not a 404")
+	assertApiError(t, actual, "Ownership transfer not found.", startTime, endTime)
+}
+
+func TestOwnershipTransfer_AdminForbidden(t *testing.T) {
+	// Given
+	owner := createUser(t)
+	admin, adminLogin := login(t)
+	tn := createTenant(t, owner.Id, "TestOwnershipTransfer")
+	createTenantUserAccess(t, tn.Id, admin.Id, auth.Admin)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		fmt.Sprintf("http://localhost:8080/tenant/%s/transfer-ownership", tn.Id),
+		&tenant.CreateOwnershipTransferDTO{UserAccountId: admin.Id},
+		adminLogin.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "Status code is not a 403")
+	assertApiError(t, actual, "User does not have permission to perform this action.", startTime, endTime)
+}
@@ -1,11 +1,14 @@
 package test
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,9 +17,13 @@ import (
 	"github.com/ccthomas/gridiron/pkg/database"
 	"github.com/ccthomas/gridiron/pkg/logger"
 	"github.com/ccthomas/gridiron/pkg/myhttp"
+	"github.com/ccthomas/gridiron/pkg/signing"
+	"github.com/ccthomas/gridiron/pkg/totp"
+	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestCreateNewUser(t *testing.T) {
@@ -27,7 +34,7 @@ func TestCreateNewUser(t *testing.T) {
 
 	userPass := &useracc.UserPassDTO{
 		Username: fmt.Sprintf("TestCreateNewUser%s", unique),
-		Password: fmt.Sprintf("TestCreateNewUser%s", unique),
+		Password: fmt.Sprintf("Password-%s", unique),
 	}
 
 	// When
@@ -47,7 +54,7 @@ func TestCreateNewUser(t *testing.T) {
 
 	cleanUpUser(t, actual.Id)
 
-	rows, err := db.Query("SELECT * FROM user_account.user_account WHERE id = $1", actual.Id)
+	rows, err := db.Query("SELECT id, username, password_hash FROM user_account.user_account WHERE id = $1", actual.Id)
 	if err != nil {
 		t.Fatal("Failed to prepare query.", err.Error())
 	}
@@ -64,8 +71,46 @@ func TestCreateNewUser(t *testing.T) {
 	assert.Equal(t, userAccount.Id, actual.Id, "User account id is incorrect")
 	assert.Equal(t, userAccount.Username, actual.Username, "User account username is incorrect")
 
-	match := useracc.CheckPasswordHash(userPass.Password, userAccount.PasswordHash)
+	match := verifyPassword(userPass.Password, userAccount.PasswordHash)
 	assert.True(t, match, "User password does not match")
+	assert.True(t, strings.HasPrefix(userAccount.PasswordHash, "$argon2id$"), "User password was not hashed with argon2id")
+}
+
+func TestCreateNewUser_WeakPassword(t *testing.T) {
+	// Given
+	unique := uuid.New().String()
+
+	cases := map[string]string{
+		"short":                "Password must be at least 12 characters.",
+		"alllowercasepassword": "Password must use at least 3 of lowercase, uppercase, digits and symbols.",
+		"Password1234":         "Password is too common.",
+		fmt.Sprintf("TestCreateNewUser%s!", unique): "Password must not contain the username.",
+	}
+
+	for password, message := range cases {
+		userPass := &useracc.UserPassDTO{
+			Username: fmt.Sprintf("TestCreateNewUser%s", unique),
+			Password: password,
+		}
+
+		// When
+
+		startTime := time.Now().UTC()
+		res, actual := sendApiReq[myhttp.ApiError](
+			t,
+			http.MethodPost,
+			"http://localhost:8080/user",
+			userPass,
+			"",
+			"",
+		)
+		endTime := time.Now().UTC()
+
+		// Then
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+		assertApiError(t, actual, message, startTime, endTime)
+	}
 }
 
 func TestCreateNewUser_UsernameTaken(t *testing.T) {
@@ -117,6 +162,7 @@ func TestLogin_WithAuthorizerContext_TenantAccessEmpty(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
 	assert.Equal(t, existing.Id, actual.UserId, "Authorizer context does not contain user id")
+	assert.NotEmpty(t, actual.TokenId, "Authorizer context does not contain token id")
 	assert.Equal(t, 0, len(actual.TenantAccess), "Authorizer context tenant access is not empty")
 }
 
@@ -145,6 +191,40 @@ func TestLogin_WithAuthorizerContext_TenantAccessNonEmpty(t *testing.T) {
 	}, actual.TenantAccess, "Authorizer context tenant access is not empty")
 }
 
+func TestLogin_RehashesLegacyBcryptPassword(t *testing.T) {
+	// Given
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	existing := createUser(t)
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte(existing.Password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal("Failed to hash password as a part of setup.", err.Error())
+	}
+
+	_, err = db.Exec("UPDATE user_account.user_account SET password_hash = $1 WHERE id = $2", string(legacyHash), existing.Id)
+	if err != nil {
+		t.Fatal("Failed to update password hash as a part of setup.", err.Error())
+	}
+
+	// When
+
+	res := basicLogin(t, existing.Username, existing.Password)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	var passwordHash string
+	err = db.QueryRow("SELECT password_hash FROM user_account.user_account WHERE id = $1", existing.Id).Scan(&passwordHash)
+	if err != nil {
+		t.Fatal("Failed to select password hash.", err.Error())
+	}
+
+	assert.True(t, strings.HasPrefix(passwordHash, "$argon2id$"), "Password was not rehashed with argon2id")
+	assert.True(t, verifyPassword(existing.Password, passwordHash), "Rehashed password does not match")
+}
+
 func TestLogin_WrongPassword(t *testing.T) {
 	// Given - login
 	existing := createUser(t)
@@ -207,3 +287,961 @@ func TestLogin_UserDoesNotExist(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
 	assertApiError(t, actual, "Invalid username or password.", startTime, endTime)
 }
+
+func TestRefreshToken(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+
+	// When
+
+	res, actual := sendApiReq[useracc.LoginResponseDTO](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/user/token/refresh",
+		&useracc.RefreshTokenDTO{RefreshToken: loginRes.RefreshToken},
+		"",
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.NotEmpty(t, actual.AccessToken, "Access token is empty")
+	assert.NotEqual(t, loginRes.RefreshToken, actual.RefreshToken, "Refresh token was not rotated")
+
+	res, ctx := sendApiReq[auth.AuthorizerContext](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/user/authorizer-context",
+		nil,
+		actual.AccessToken,
+		"",
+	)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, existing.Id, ctx.UserId, "Authorizer context does not contain user id")
+}
+
+func TestRefreshToken_ReuseRevokesSession(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+
+	res, refreshed := sendApiReq[useracc.LoginResponseDTO](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/user/token/refresh",
+		&useracc.RefreshTokenDTO{RefreshToken: loginRes.RefreshToken},
+		"",
+		"",
+	)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/user/token/refresh",
+		&useracc.RefreshTokenDTO{RefreshToken: loginRes.RefreshToken},
+		"",
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Status code is not a 401")
+	assertApiError(t, actual, "Invalid refresh token.", startTime, endTime)
+
+	res, _ = sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/user/token/refresh",
+		&useracc.RefreshTokenDTO{RefreshToken: refreshed.RefreshToken},
+		"",
+		"",
+	)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Rotated refresh token was not revoked")
+}
+
+func TestLogout_RevokesAccessToken(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodPost,
+		"http://localhost:8080/user/logout",
+		nil,
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/user/authorizer-context",
+		nil,
+		loginRes.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Status code is not a 401")
+	assertApiError(t, actual, "Token has been revoked.", startTime, endTime)
+}
+
+func TestAuthorizer_ExpiredSession(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	_, err := db.Exec("UPDATE user_account.user_session SET expires_at = $1 WHERE user_account_id = $2", time.Now().Add(-time.Minute), existing.Id)
+	if err != nil {
+		t.Fatal("Failed to expire session as a part of setup.", err.Error())
+	}
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/user/authorizer-context",
+		nil,
+		loginRes.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Status code is not a 401")
+	assertApiError(t, actual, "Token has expired.", startTime, endTime)
+}
+
+func TestChangePassword(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+	newPassword := fmt.Sprintf("new%s", existing.Password)
+
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodPut,
+		"http://localhost:8080/user/password",
+		&useracc.ChangePasswordDTO{CurrentPassword: existing.Password, NewPassword: newPassword},
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	var passwordHash string
+	err := db.QueryRow("SELECT password_hash FROM user_account.user_account WHERE id = $1", existing.Id).Scan(&passwordHash)
+	if err != nil {
+		t.Fatal("Failed to select user.", err.Error())
+	}
+
+	assert.True(t, verifyPassword(newPassword, passwordHash), "User password was not changed")
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPut,
+		"http://localhost:8080/user/password",
+		&useracc.ChangePasswordDTO{CurrentPassword: "Wrong Password", NewPassword: "new password"},
+		loginRes.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "Current password is incorrect.", startTime, endTime)
+}
+
+func TestPasswordReset_Confirm(t *testing.T) {
+	// Given
+	existing := createUser(t)
+	token := uuid.New().String()
+	newPassword := fmt.Sprintf("reset%s", existing.Password)
+
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	_, err := db.Exec(
+		"INSERT INTO user_account.password_reset_token (token_hash, user_account_id, expires_at) VALUES ($1, $2, $3)",
+		auth.HashToken(token), existing.Id, time.Now().Add(time.Hour),
+	)
+	if err != nil {
+		t.Fatal("Failed to insert password reset token as a part of setup.", err.Error())
+	}
+
+	dto := &useracc.PasswordResetConfirmDTO{Token: token, NewPassword: newPassword}
+
+	// When
+
+	res := sendApiReqNoContent(t, http.MethodPost, "http://localhost:8080/user/password/reset/confirm", dto, "", "")
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	var passwordHash string
+	err = db.QueryRow("SELECT password_hash FROM user_account.user_account WHERE id = $1", existing.Id).Scan(&passwordHash)
+	if err != nil {
+		t.Fatal("Failed to select user.", err.Error())
+	}
+
+	assert.True(t, verifyPassword(newPassword, passwordHash), "User password was not reset")
+
+	res = sendApiReqNoContent(t, http.MethodPost, "http://localhost:8080/user/password/reset/confirm", dto, "", "")
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Reset token was used twice")
+}
+
+func TestPasswordReset_ConfirmPasswordContainsUsername(t *testing.T) {
+	// Given
+	existing := createUser(t)
+	token := uuid.New().String()
+
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	_, err := db.Exec(
+		"INSERT INTO user_account.password_reset_token (token_hash, user_account_id, expires_at) VALUES ($1, $2, $3)",
+		auth.HashToken(token), existing.Id, time.Now().Add(time.Hour),
+	)
+	if err != nil {
+		t.Fatal("Failed to insert password reset token as a part of setup.", err.Error())
+	}
+
+	dto := &useracc.PasswordResetConfirmDTO{Token: token, NewPassword: fmt.Sprintf("%s!Reset", existing.Username)}
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](t, http.MethodPost, "http://localhost:8080/user/password/reset/confirm", dto, "", "")
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "Password must not contain the username.", startTime, endTime)
+
+	var usedAt sql.NullTime
+	err = db.QueryRow("SELECT used_at FROM user_account.password_reset_token WHERE token_hash = $1", auth.HashToken(token)).Scan(&usedAt)
+	if err != nil {
+		t.Fatal("Failed to select password reset token.", err.Error())
+	}
+
+	assert.False(t, usedAt.Valid, "Reset token was consumed by a rejected password")
+}
+
+func TestPasswordReset_UnknownUser(t *testing.T) {
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodPost,
+		"http://localhost:8080/user/password/reset",
+		&useracc.PasswordResetRequestDTO{Username: uuid.New().String()},
+		"",
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusAccepted, res.StatusCode, "Status code is not a 202")
+}
+
+func TestLogin_ThrottledAfterRepeatedFailures(t *testing.T) {
+	// Given
+	existing := createUser(t)
+
+	loginWith := func(password string) *http.Response {
+		reqLogin, err := http.NewRequest(http.MethodPost, "http://localhost:8080/user/login", nil)
+		if err != nil {
+			t.Fatal("Failed to construct request", err.Error())
+		}
+
+		reqLogin.SetBasicAuth(existing.Username, password)
+		res, err := http.DefaultClient.Do(reqLogin)
+		if err != nil {
+			t.Fatal("Api request failed.", err.Error())
+		}
+
+		return res
+	}
+
+	for i := 0; i <= useracc.DefaultUserLoginPolicy.FreeAttempts; i++ {
+		res := loginWith("Wrong Password")
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	}
+
+	// When
+	startTime := time.Now().UTC()
+	res := loginWith(existing.Password)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	var actual myhttp.ApiError
+	err := json.NewDecoder(res.Body).Decode(&actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode, "Status code is not a 429")
+	assert.NotEmpty(t, res.Header.Get("Retry-After"), "Retry-After header is missing")
+	assertApiError(t, actual, "Too many login attempts. Try again later.", startTime, endTime)
+}
+
+func TestJwks_ContainsAccessTokenKey(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+
+	token, _, err := new(jwt.Parser).ParseUnverified(loginRes.AccessToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// When
+
+	res, actual := sendApiReq[signing.JwkSet](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/.well-known/jwks.json",
+		nil,
+		"",
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	var found *signing.Jwk
+	for i := range actual.Keys {
+		if actual.Keys[i].Kid == token.Header["kid"] {
+			found = &actual.Keys[i]
+		}
+	}
+
+	assert.NotNil(t, found, "Jwks does not contain the access token kid")
+	assert.Equal(t, token.Method.Alg(), found.Alg, "Jwk alg does not match the access token alg")
+	assert.NotEqual(t, "HS256", found.Alg, "Access token is signed with a symmetric key")
+}
+
+func TestTokenAuthorizer_RejectsUnexpectedAlgorithm(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+
+	token, _, err := new(jwt.Parser).ParseUnverified(loginRes.AccessToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": existing.Id,
+		"sid": token.Claims.(jwt.MapClaims)["sid"],
+		"iss": token.Claims.(jwt.MapClaims)["iss"],
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	forged.Header["kid"] = token.Header["kid"]
+
+	forgedToken, err := forged.SignedString([]byte("secret-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/user/authorizer-context",
+		nil,
+		forgedToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Status code is not a 401")
+	assertApiError(t, actual, "Token is invalid.", startTime, endTime)
+}
+
+func TestGetProfile(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+
+	// When
+
+	res, actual := sendApiReq[useracc.UserProfileDTO](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/user/me",
+		nil,
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, existing.Id, actual.Id, "Profile id does not match")
+	assert.Equal(t, existing.Username, actual.Username, "Profile username does not match")
+	assert.Nil(t, actual.DisplayName, "Profile display name is not empty")
+	assert.False(t, actual.CreatedAt.IsZero(), "Profile created at is empty")
+}
+
+func TestUpdateProfile(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+	displayName := "Test User"
+	email := fmt.Sprintf("%s@example.com", existing.Id)
+	timezone := "America/Chicago"
+
+	// When
+
+	res, actual := sendApiReq[useracc.UserProfileDTO](
+		t,
+		http.MethodPatch,
+		"http://localhost:8080/user/me",
+		&useracc.UpdateProfileDTO{DisplayName: &displayName, Email: &email, Timezone: &timezone},
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, &displayName, actual.DisplayName, "Display name was not updated")
+	assert.Equal(t, &email, actual.Email, "Email was not updated")
+	assert.Equal(t, &timezone, actual.Timezone, "Timezone was not updated")
+	assert.Nil(t, actual.FavoriteTeam, "Favorite team was changed")
+
+	// When - clear display name
+
+	blank := ""
+	res, actual = sendApiReq[useracc.UserProfileDTO](
+		t,
+		http.MethodPatch,
+		"http://localhost:8080/user/me",
+		&useracc.UpdateProfileDTO{DisplayName: &blank},
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then - clear display name
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Nil(t, actual.DisplayName, "Display name was not cleared")
+	assert.Equal(t, &email, actual.Email, "Email was changed")
+}
+
+func TestUpdateProfile_InvalidTimezone(t *testing.T) {
+	// Given
+	_, loginRes := login(t)
+	timezone := "Not/AZone"
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPatch,
+		"http://localhost:8080/user/me",
+		&useracc.UpdateProfileDTO{Timezone: &timezone},
+		loginRes.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "Timezone is invalid.", startTime, endTime)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+	owner := createUser(t)
+	tn := createTenant(t, owner.Id, fmt.Sprintf("TestTenant%s", owner.Id))
+	createTenantUserAccess(t, tn.Id, existing.Id, auth.Editor)
+
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodDelete,
+		"http://localhost:8080/user/me",
+		&useracc.DeleteAccountDTO{Password: existing.Password},
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM tenant.tenant_user_access WHERE user_account_id = $1", existing.Id).Scan(&count)
+	if err != nil {
+		t.Fatal("Failed to count tenant user access.", err.Error())
+	}
+
+	assert.Equal(t, 0, count, "Tenant user access was not deleted")
+
+	res, _ = sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodGet,
+		"http://localhost:8080/user/me",
+		nil,
+		loginRes.AccessToken,
+		"",
+	)
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Status code is not a 401")
+}
+
+func TestDeleteAccount_SoleOwner(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+	createTenant(t, existing.Id, fmt.Sprintf("TestTenant%s", existing.Id))
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodDelete,
+		"http://localhost:8080/user/me",
+		&useracc.DeleteAccountDTO{Password: existing.Password},
+		loginRes.AccessToken,
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode, "Status code is not a 409")
+	assertApiError(t, actual, "User is the sole owner of one or more tenants.", startTime, endTime)
+}
+
+// startOidcLogin calls the login endpoint without following the redirect and returns the provider url.
+func startOidcLogin(t *testing.T) string {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get("http://localhost:8080/user/oidc/login")
+	if err != nil {
+		t.Fatal("Api request failed.", err.Error())
+	}
+
+	assert.Equal(t, http.StatusFound, res.StatusCode, "Status code is not a 302")
+	return res.Header.Get("Location")
+}
+
+func oidcCallbackUrl(code string, state string) string {
+	return fmt.Sprintf("http://localhost:8080/user/oidc/callback?%s", url.Values{"code": {code}, "state": {state}}.Encode())
+}
+
+func TestOidcLogin_CreatesAndReusesUser(t *testing.T) {
+	// Given
+	provider := startOidcProvider(t)
+	subject := uuid.New().String()
+	email := fmt.Sprintf("%s@example.com", subject)
+
+	code, state := provider.Authorize(t, startOidcLogin(t), subject, email)
+
+	// When
+
+	res, actual := sendApiReq[useracc.LoginResponseDTO](t, http.MethodGet, oidcCallbackUrl(code, state), nil, "", "")
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	res, profile := sendApiReq[useracc.UserProfileDTO](t, http.MethodGet, "http://localhost:8080/user/me", nil, actual.AccessToken, "")
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	cleanUpUser(t, profile.Id)
+
+	assert.Equal(t, email, profile.Username, "Username was not taken from the id token")
+
+	// When - log in again
+
+	code, state = provider.Authorize(t, startOidcLogin(t), subject, email)
+	res, actual = sendApiReq[useracc.LoginResponseDTO](t, http.MethodGet, oidcCallbackUrl(code, state), nil, "", "")
+
+	// Then - log in again
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	res, ctx := sendApiReq[auth.AuthorizerContext](t, http.MethodGet, "http://localhost:8080/user/authorizer-context", nil, actual.AccessToken, "")
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, profile.Id, ctx.UserId, "Second login did not resolve to the same user")
+}
+
+func TestOidcLogin_SetsFirstPasswordAndDeletesAccount(t *testing.T) {
+	// Given
+	provider := startOidcProvider(t)
+	subject := uuid.New().String()
+
+	code, state := provider.Authorize(t, startOidcLogin(t), subject, "")
+	res, loginRes := sendApiReq[useracc.LoginResponseDTO](t, http.MethodGet, oidcCallbackUrl(code, state), nil, "", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	_, ctx := sendApiReq[auth.AuthorizerContext](t, http.MethodGet, "http://localhost:8080/user/authorizer-context", nil, loginRes.AccessToken, "")
+	cleanUpUser(t, ctx.UserId)
+
+	newPassword := fmt.Sprintf("Password%s!", uuid.New().String())
+
+	// When
+
+	res = sendApiReqNoContent(
+		t,
+		http.MethodPut,
+		"http://localhost:8080/user/password",
+		&useracc.ChangePasswordDTO{NewPassword: newPassword},
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	var passwordHash string
+	err := db.QueryRow("SELECT password_hash FROM user_account.user_account WHERE id = $1", ctx.UserId).Scan(&passwordHash)
+	if err != nil {
+		t.Fatal("Failed to select user.", err.Error())
+	}
+
+	assert.True(t, verifyPassword(newPassword, passwordHash), "First password was not set")
+
+	// When - delete the account
+
+	res = sendApiReqNoContent(
+		t,
+		http.MethodDelete,
+		"http://localhost:8080/user/me",
+		&useracc.DeleteAccountDTO{Password: newPassword},
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then - delete the account
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+}
+
+func TestOidcLogin_DeletesAccountWithoutPassword(t *testing.T) {
+	// Given
+	provider := startOidcProvider(t)
+	subject := uuid.New().String()
+
+	code, state := provider.Authorize(t, startOidcLogin(t), subject, "")
+	res, loginRes := sendApiReq[useracc.LoginResponseDTO](t, http.MethodGet, oidcCallbackUrl(code, state), nil, "", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	_, ctx := sendApiReq[auth.AuthorizerContext](t, http.MethodGet, "http://localhost:8080/user/authorizer-context", nil, loginRes.AccessToken, "")
+	cleanUpUser(t, ctx.UserId)
+
+	// When
+
+	res = sendApiReqNoContent(
+		t,
+		http.MethodDelete,
+		"http://localhost:8080/user/me",
+		&useracc.DeleteAccountDTO{},
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+}
+
+func TestOidcLogin_StateCannotBeReused(t *testing.T) {
+	// Given
+	provider := startOidcProvider(t)
+	subject := uuid.New().String()
+
+	code, state := provider.Authorize(t, startOidcLogin(t), subject, "")
+	res, actual := sendApiReq[useracc.LoginResponseDTO](t, http.MethodGet, oidcCallbackUrl(code, state), nil, "", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	_, ctx := sendApiReq[auth.AuthorizerContext](t, http.MethodGet, "http://localhost:8080/user/authorizer-context", nil, actual.AccessToken, "")
+	cleanUpUser(t, ctx.UserId)
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, apiErr := sendApiReq[myhttp.ApiError](t, http.MethodGet, oidcCallbackUrl(code, state), nil, "", "")
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, apiErr, "Invalid OIDC login.", startTime, endTime)
+}
+
+func TestOidcLink(t *testing.T) {
+	// Given
+	provider := startOidcProvider(t)
+	existing, loginRes := login(t)
+	subject := uuid.New().String()
+
+	res, link := sendApiReq[useracc.OidcAuthorizationDTO](t, http.MethodPost, "http://localhost:8080/user/oidc/link", nil, loginRes.AccessToken, "")
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	code, state := provider.Authorize(t, link.AuthorizationUrl, subject, "")
+
+	// When
+
+	res, actual := sendApiReq[useracc.LoginResponseDTO](t, http.MethodGet, oidcCallbackUrl(code, state), nil, "", "")
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	_, ctx := sendApiReq[auth.AuthorizerContext](t, http.MethodGet, "http://localhost:8080/user/authorizer-context", nil, actual.AccessToken, "")
+	assert.Equal(t, existing.Id, ctx.UserId, "Identity was not linked to the existing user")
+
+	db := database.ConnectPostgres()
+	defer db.Close()
+
+	var userId string
+	err := db.QueryRow("SELECT user_account_id FROM user_account.external_identity WHERE issuer = $1 AND subject = $2", os.Getenv("OIDC_ISSUER"), subject).Scan(&userId)
+	if err != nil {
+		t.Fatal("Failed to select external identity.", err.Error())
+	}
+
+	assert.Equal(t, existing.Id, userId, "External identity is not linked to the existing user")
+}
+
+// basicLogin logs in with the username and password and returns the raw response.
+func basicLogin(t *testing.T, username string, password string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, "http://localhost:8080/user/login", nil)
+	if err != nil {
+		t.Fatal("Failed to construct request", err.Error())
+	}
+
+	req.SetBasicAuth(username, password)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Api request failed.", err.Error())
+	}
+
+	return res
+}
+
+// enableTotp enrolls the user in TOTP MFA and returns the secret and recovery codes.
+func enableTotp(t *testing.T, accessToken string) (string, []string) {
+	res, enrollment := sendApiReq[useracc.TotpEnrollmentDTO](t, http.MethodPost, "http://localhost:8080/user/mfa/totp", nil, accessToken, "")
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Contains(t, enrollment.ProvisioningUri, "otpauth://totp/", "Provisioning uri is invalid")
+
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, recovery := sendApiReq[useracc.RecoveryCodesDTO](t, http.MethodPost, "http://localhost:8080/user/mfa/totp/verify", &useracc.MfaCodeDTO{Code: code}, accessToken, "")
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.Equal(t, useracc.RecoveryCodeCount, len(recovery.RecoveryCodes), "Recovery codes were not returned")
+
+	return enrollment.Secret, recovery.RecoveryCodes
+}
+
+func TestMfaLogin_WithRecoveryCode(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+	_, recoveryCodes := enableTotp(t, loginRes.AccessToken)
+
+	res := basicLogin(t, existing.Username, existing.Password)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+	var challenge useracc.MfaChallengeDTO
+	err := json.NewDecoder(res.Body).Decode(&challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, challenge.MfaRequired, "Login did not require mfa")
+	assert.NotEmpty(t, challenge.MfaToken, "Mfa token is empty")
+
+	// When
+
+	res, actual := sendApiReq[useracc.LoginResponseDTO](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/user/login/mfa",
+		&useracc.MfaLoginDTO{MfaToken: challenge.MfaToken, Code: recoveryCodes[0]},
+		"",
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.NotEmpty(t, actual.AccessToken, "Access token is empty")
+
+	res, _ = sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/user/login/mfa",
+		&useracc.MfaLoginDTO{MfaToken: challenge.MfaToken, Code: recoveryCodes[0]},
+		"",
+		"",
+	)
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "Mfa challenge was accepted twice")
+}
+
+func TestMfaLogin_InvalidCode(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+	enableTotp(t, loginRes.AccessToken)
+
+	res := basicLogin(t, existing.Username, existing.Password)
+
+	var challenge useracc.MfaChallengeDTO
+	err := json.NewDecoder(res.Body).Decode(&challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// When
+
+	startTime := time.Now().UTC()
+	res, actual := sendApiReq[myhttp.ApiError](
+		t,
+		http.MethodPost,
+		"http://localhost:8080/user/login/mfa",
+		&useracc.MfaLoginDTO{MfaToken: challenge.MfaToken, Code: "000000x"},
+		"",
+		"",
+	)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	assertApiError(t, actual, "Mfa code is invalid.", startTime, endTime)
+}
+
+func TestMfaLogin_ThrottledAfterRepeatedInvalidCodes(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+	enableTotp(t, loginRes.AccessToken)
+
+	for i := 0; i <= useracc.DefaultUserLoginPolicy.FreeAttempts; i++ {
+		res := basicLogin(t, existing.Username, existing.Password)
+		assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+
+		var challenge useracc.MfaChallengeDTO
+		err := json.NewDecoder(res.Body).Decode(&challenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, _ = sendApiReq[myhttp.ApiError](
+			t,
+			http.MethodPost,
+			"http://localhost:8080/user/login/mfa",
+			&useracc.MfaLoginDTO{MfaToken: challenge.MfaToken, Code: "000000x"},
+			"",
+			"",
+		)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, "Status code is not a 400")
+	}
+
+	// When
+	startTime := time.Now().UTC()
+	res := basicLogin(t, existing.Username, existing.Password)
+	endTime := time.Now().UTC()
+
+	// Then
+
+	var actual myhttp.ApiError
+	err := json.NewDecoder(res.Body).Decode(&actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode, "Status code is not a 429")
+	assert.NotEmpty(t, res.Header.Get("Retry-After"), "Retry-After header is missing")
+	assertApiError(t, actual, "Too many login attempts. Try again later.", startTime, endTime)
+}
+
+func TestMfa_Disable(t *testing.T) {
+	// Given
+	existing, loginRes := login(t)
+	_, recoveryCodes := enableTotp(t, loginRes.AccessToken)
+
+	// When
+
+	res := sendApiReqNoContent(
+		t,
+		http.MethodDelete,
+		"http://localhost:8080/user/mfa/totp",
+		&useracc.MfaCodeDTO{Code: recoveryCodes[1]},
+		loginRes.AccessToken,
+		"",
+	)
+
+	// Then
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode, "Status code is not a 204")
+
+	res = basicLogin(t, existing.Username, existing.Password)
+	var tokens useracc.LoginResponseDTO
+	err := json.NewDecoder(res.Body).Decode(&tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusOK, res.StatusCode, "Status code is not a 200")
+	assert.NotEmpty(t, tokens.AccessToken, "Login still requires mfa")
+}